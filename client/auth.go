@@ -0,0 +1,65 @@
+package client
+
+import (
+	"encoding/base64"
+	"net/http"
+	"strings"
+)
+
+// Auth adds credentials to the requests a Client sends.
+type Auth interface {
+	// Apply sets the credentials on req.
+	Apply(req *http.Request)
+
+	// Secrets returns the values that must never be shown in an error
+	// message, in any of the forms they are sent in.
+	Secrets() []string
+}
+
+// BearerToken authenticates requests with an "Authorization: Bearer" header.
+type BearerToken string
+
+// Apply implements Auth.
+func (t BearerToken) Apply(req *http.Request) {
+	req.Header.Set("Authorization", "Bearer "+string(t))
+}
+
+// Secrets implements Auth.
+func (t BearerToken) Secrets() []string {
+	return []string{string(t)}
+}
+
+// BasicAuth authenticates requests with HTTP basic authentication.
+type BasicAuth struct {
+	Username string
+	Password string
+}
+
+// Apply implements Auth.
+func (b BasicAuth) Apply(req *http.Request) {
+	req.SetBasicAuth(b.Username, b.Password)
+}
+
+// Secrets implements Auth.
+func (b BasicAuth) Secrets() []string {
+	encoded := base64.StdEncoding.EncodeToString(
+		[]byte(b.Username + ":" + b.Password),
+	)
+
+	return []string{b.Password, encoded}
+}
+
+// redact replaces every secret of auth found in msg.
+func redact(msg string, auth Auth) string {
+	if auth == nil {
+		return msg
+	}
+
+	for _, secret := range auth.Secrets() {
+		if secret != "" {
+			msg = strings.ReplaceAll(msg, secret, "[REDACTED]")
+		}
+	}
+
+	return msg
+}
@@ -0,0 +1,67 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestClientAuth(t *testing.T) {
+	testCases := []struct {
+		name           string
+		auth           Auth
+		expectedHeader string
+	}{
+		{
+			name:           "Bearer",
+			auth:           BearerToken("s3cr3t"),
+			expectedHeader: "Bearer s3cr3t",
+		},
+		{
+			name:           "Basic",
+			auth:           BasicAuth{Username: "user", Password: "s3cr3t"},
+			expectedHeader: "Basic dXNlcjpzM2NyM3Q=",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			url, cleanup := mockServer(func(w http.ResponseWriter, r *http.Request) {
+				header := r.Header.Get("Authorization")
+				if header != tc.expectedHeader {
+					t.Errorf(
+						"Expected Authorization: %s, but got: %s instead",
+						tc.expectedHeader,
+						header,
+					)
+				}
+
+				// Echo the credentials back like a careless server would.
+				w.WriteHeader(http.StatusUnauthorized)
+				w.Write([]byte("bad credentials: " + header))
+			})
+
+			defer cleanup()
+
+			c := New(url)
+			c.Auth = tc.auth
+
+			err := c.Delete(context.Background(), 1)
+			if !errors.Is(err, ErrUnauthorized) {
+				t.Fatalf(
+					"Expected error: %q, but got: %q instead",
+					ErrUnauthorized,
+					err,
+				)
+			}
+
+			for _, secret := range tc.auth.Secrets() {
+				if strings.Contains(err.Error(), secret) {
+					t.Errorf("Error message leaks a secret: %q", err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Result is the outcome of a batch operation on a single item.
+type Result struct {
+	ID  int
+	Err error
+}
+
+// BatchError reports the items that failed in a batch operation. It matches
+// any of the underlying errors with errors.Is, so a batch where an item was
+// missing satisfies errors.Is(err, ErrNotFound).
+type BatchError struct {
+	Failed []Result
+	Total  int
+}
+
+func (e *BatchError) Error() string {
+	msgs := make([]string, len(e.Failed))
+
+	for i, r := range e.Failed {
+		msgs[i] = fmt.Sprintf("item %d: %s", r.ID, r.Err)
+	}
+
+	return fmt.Sprintf(
+		"%d of %d items failed: %s",
+		len(e.Failed), e.Total, strings.Join(msgs, "; "),
+	)
+}
+
+// Unwrap returns the errors of the failed items.
+func (e *BatchError) Unwrap() []error {
+	errs := make([]error, len(e.Failed))
+
+	for i, r := range e.Failed {
+		errs[i] = r.Err
+	}
+
+	return errs
+}
+
+// Is reports whether any of the failed items matches target.
+func (e *BatchError) Is(target error) bool {
+	for _, err := range e.Unwrap() {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Batch runs op for each of the ids using at most workers concurrent calls.
+// The results are returned in the order of ids, together with a *BatchError
+// if any of the calls failed. A workers value below 1 runs the calls one at
+// a time.
+func Batch(
+	ctx context.Context, ids []int, workers int,
+	op func(ctx context.Context, id int) error,
+) ([]Result, error) {
+	if workers < 1 {
+		workers = 1
+	}
+
+	if workers > len(ids) {
+		workers = len(ids)
+	}
+
+	results := make([]Result, len(ids))
+	jobs := make(chan int)
+
+	var wg sync.WaitGroup
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for i := range jobs {
+				results[i] = Result{ID: ids[i], Err: op(ctx, ids[i])}
+			}
+		}()
+	}
+
+	for i := range ids {
+		jobs <- i
+	}
+
+	close(jobs)
+	wg.Wait()
+
+	batchErr := &BatchError{Total: len(ids)}
+
+	for _, r := range results {
+		if r.Err != nil {
+			batchErr.Failed = append(batchErr.Failed, r)
+		}
+	}
+
+	if len(batchErr.Failed) > 0 {
+		return results, batchErr
+	}
+
+	return results, nil
+}
+
+// CompleteMany marks the items identified by ids as done, sending at most
+// workers requests at a time.
+func (c *Client) CompleteMany(
+	ctx context.Context, ids []int, workers int,
+) ([]Result, error) {
+	return Batch(ctx, ids, workers, c.Complete)
+}
+
+// ReopenMany marks the items identified by ids as pending again, sending at
+// most workers requests at a time.
+func (c *Client) ReopenMany(
+	ctx context.Context, ids []int, workers int,
+) ([]Result, error) {
+	return Batch(ctx, ids, workers, c.Reopen)
+}
+
+// DeleteMany removes the items identified by ids, sending at most workers
+// requests at a time. Items identified by their position shift when an
+// earlier item is deleted, so callers using positions should delete from
+// the highest to the lowest one at a time.
+func (c *Client) DeleteMany(
+	ctx context.Context, ids []int, workers int,
+) ([]Result, error) {
+	return Batch(ctx, ids, workers, c.Delete)
+}
@@ -0,0 +1,107 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCompleteMany(t *testing.T) {
+	const workers = 3
+
+	var (
+		mu       sync.Mutex
+		inFlight int
+		peak     int
+		requests int32
+	)
+
+	url, cleanup := mockServer(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+
+		mu.Lock()
+		inFlight++
+		if inFlight > peak {
+			peak = inFlight
+		}
+		mu.Unlock()
+
+		// Hold the request long enough for the workers to overlap.
+		time.Sleep(10 * time.Millisecond)
+
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+
+		if r.URL.Path == "/todo/4" || r.URL.Path == "/todo/7" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte("404 - not found"))
+
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	defer cleanup()
+
+	ids := []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+
+	results, err := New(url).CompleteMany(context.Background(), ids, workers)
+
+	if !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Expected error: %q, but got: %q instead", ErrNotFound, err)
+	}
+
+	var batchErr *BatchError
+	if !errors.As(err, &batchErr) {
+		t.Fatalf("Expected a *BatchError, but got: %T instead", err)
+	}
+
+	if len(batchErr.Failed) != 2 || batchErr.Total != len(ids) {
+		t.Errorf("Unexpected batch error: %q", batchErr)
+	}
+
+	if int(requests) != len(ids) {
+		t.Errorf("Expected %d requests, but got: %d instead", len(ids), requests)
+	}
+
+	if peak > workers {
+		t.Errorf("Expected at most %d concurrent requests, but got: %d", workers, peak)
+	}
+
+	for i, r := range results {
+		if r.ID != ids[i] {
+			t.Errorf("Expected result %d for item %d, but got: %d", i, ids[i], r.ID)
+		}
+
+		failed := r.ID == 4 || r.ID == 7
+		if failed != (r.Err != nil) {
+			t.Errorf("Unexpected error for item %d: %v", r.ID, r.Err)
+		}
+	}
+}
+
+func TestBatchNoErrors(t *testing.T) {
+	var sum int64
+
+	results, err := Batch(context.Background(), []int{1, 2, 3}, 0,
+		func(ctx context.Context, id int) error {
+			atomic.AddInt64(&sum, int64(id))
+
+			return nil
+		},
+	)
+
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %q instead", err)
+	}
+
+	if len(results) != 3 || sum != 6 {
+		t.Errorf("Unexpected results: %v (sum %d)", results, sum)
+	}
+}
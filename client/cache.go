@@ -0,0 +1,288 @@
+package client
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/mycok/todo_list_client/internal/fsutil"
+)
+
+// cacheEntryExt is the extension of the files DiskCache stores responses in.
+const cacheEntryExt = ".json"
+
+// DiskCache stores the bodies of GET responses in Dir, one file per URL and
+// set of credentials, along with the validators needed to revalidate them.
+type DiskCache struct {
+	Dir string
+}
+
+// CacheStats describes the content of a DiskCache.
+type CacheStats struct {
+	Entries int
+	Size    int64
+
+	// Hits is the number of times a stored body was served because the
+	// server answered 304 Not Modified.
+	Hits int
+
+	// Oldest and Newest are the times the least and most recently
+	// validated entries were last confirmed by the server.
+	Oldest time.Time
+	Newest time.Time
+}
+
+type cacheEntry struct {
+	URL          string      `json:"url"`
+	ETag         string      `json:"etag,omitempty"`
+	LastModified string      `json:"last_modified,omitempty"`
+	Header       http.Header `json:"header"`
+	Body         []byte      `json:"body"`
+	ValidatedAt  time.Time   `json:"validated_at"`
+	Hits         int         `json:"hits"`
+}
+
+// path returns the file the response to req is stored in. The credentials
+// are part of the key, so that a body fetched by one user is never served
+// to another.
+func (d *DiskCache) path(req *http.Request) string {
+	sum := sha256.Sum256([]byte(
+		req.URL.String() + "\n" + req.Header.Get("Authorization"),
+	))
+
+	return filepath.Join(d.Dir, hex.EncodeToString(sum[:])+cacheEntryExt)
+}
+
+func (d *DiskCache) get(req *http.Request) (cacheEntry, bool) {
+	var e cacheEntry
+
+	data, err := os.ReadFile(d.path(req))
+	if err != nil {
+		return e, false
+	}
+
+	// A corrupted entry is a miss, and is replaced by the next response.
+	if err := json.Unmarshal(data, &e); err != nil || e.URL != req.URL.String() {
+		return e, false
+	}
+
+	return e, true
+}
+
+func (d *DiskCache) put(req *http.Request, e cacheEntry) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	return fsutil.WriteFileAtomic(d.path(req), data, 0o600)
+}
+
+// Clear removes every stored response and returns how many there were.
+func (d *DiskCache) Clear() (int, error) {
+	files, err := d.entries()
+	if err != nil {
+		return 0, err
+	}
+
+	for i, f := range files {
+		if err := os.Remove(f); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return i, err
+		}
+	}
+
+	return len(files), nil
+}
+
+// Stats returns the number and total size of the stored responses.
+func (d *DiskCache) Stats() (CacheStats, error) {
+	var stats CacheStats
+
+	files, err := d.entries()
+	if err != nil {
+		return stats, err
+	}
+
+	for _, f := range files {
+		data, err := os.ReadFile(f)
+		if err != nil {
+			return stats, err
+		}
+
+		stats.Entries++
+		stats.Size += int64(len(data))
+
+		var e cacheEntry
+
+		if json.Unmarshal(data, &e) != nil {
+			continue
+		}
+
+		stats.Hits += e.Hits
+
+		if stats.Oldest.IsZero() || e.ValidatedAt.Before(stats.Oldest) {
+			stats.Oldest = e.ValidatedAt
+		}
+
+		if e.ValidatedAt.After(stats.Newest) {
+			stats.Newest = e.ValidatedAt
+		}
+	}
+
+	return stats, nil
+}
+
+func (d *DiskCache) entries() ([]string, error) {
+	dirEntries, err := os.ReadDir(d.Dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+
+	for _, e := range dirEntries {
+		if !e.IsDir() && strings.HasSuffix(e.Name(), cacheEntryExt) {
+			files = append(files, filepath.Join(d.Dir, e.Name()))
+		}
+	}
+
+	return files, nil
+}
+
+// CacheTransport is an http.RoundTripper that makes GET requests
+// conditional. Responses carrying an ETag or Last-Modified header are
+// stored in Cache, and the next request for the same URL sends them back in
+// If-None-Match and If-Modified-Since. A 304 Not Modified answer is turned
+// into a 200 OK response with the stored body.
+type CacheTransport struct {
+	Base  http.RoundTripper
+	Cache *DiskCache
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *CacheTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	if req.Method != http.MethodGet || t.Cache == nil {
+		return base.RoundTrip(req)
+	}
+
+	entry, ok := t.Cache.get(req)
+
+	r := req
+
+	if ok {
+		r = req.Clone(req.Context())
+
+		if entry.ETag != "" {
+			r.Header.Set("If-None-Match", entry.ETag)
+		}
+
+		if entry.LastModified != "" {
+			r.Header.Set("If-Modified-Since", entry.LastModified)
+		}
+	}
+
+	resp, err := base.RoundTrip(r)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case ok && resp.StatusCode == http.StatusNotModified:
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+
+		entry.ValidatedAt = time.Now()
+		entry.Hits++
+
+		// Failing to record the hit does not make the body stale.
+		t.Cache.put(req, entry)
+
+		return cachedResponse(req, resp, entry), nil
+
+	case resp.StatusCode == http.StatusOK:
+		return t.store(req, resp)
+	}
+
+	return resp, nil
+}
+
+// store saves the body of resp when it carries a validator, and returns a
+// response that reads the body again. Otherwise, it drops the body stored
+// for req, which the server can no longer confirm.
+func (t *CacheTransport) store(req *http.Request, resp *http.Response) (*http.Response, error) {
+	etag := resp.Header.Get("ETag")
+	lastModified := resp.Header.Get("Last-Modified")
+
+	if etag == "" && lastModified == "" {
+		os.Remove(t.Cache.path(req))
+
+		return resp, nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	if err != nil {
+		return nil, err
+	}
+
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	// Caching is best effort: the response is returned even if it could
+	// not be stored.
+	t.Cache.put(req, cacheEntry{
+		URL:          req.URL.String(),
+		ETag:         etag,
+		LastModified: lastModified,
+		Header:       resp.Header,
+		Body:         body,
+		ValidatedAt:  time.Now(),
+	})
+
+	return resp, nil
+}
+
+// cachedResponse builds the 200 OK response served for a 304 answer, with
+// the stored headers updated by the ones the server sent along.
+func cachedResponse(req *http.Request, notModified *http.Response, e cacheEntry) *http.Response {
+	header := e.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+
+	for k, v := range notModified.Header {
+		if k != "Content-Length" {
+			header[k] = v
+		}
+	}
+
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         notModified.Proto,
+		ProtoMajor:    notModified.ProtoMajor,
+		ProtoMinor:    notModified.ProtoMinor,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(e.Body)),
+		ContentLength: int64(len(e.Body)),
+		Request:       req,
+	}
+}
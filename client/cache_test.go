@@ -0,0 +1,146 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func cacheClient(url string, cache *DiskCache) *Client {
+	c := New(url)
+	c.HTTPClient.Transport = &CacheTransport{
+		Base:  c.HTTPClient.Transport,
+		Cache: cache,
+	}
+
+	return c
+}
+
+func TestCacheTransport(t *testing.T) {
+	var (
+		version     = 1
+		validators  = true
+		requests    int
+		notModified int
+	)
+
+	url, cleanup := mockServer(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+
+		etag := fmt.Sprintf(`"v%d"`, version)
+
+		if validators {
+			w.Header().Set("ETag", etag)
+			w.Header().Set("Last-Modified", "Mon, 28 Oct 2019 12:23:38 GMT")
+		}
+
+		if validators && r.Header.Get("If-None-Match") == etag {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+
+			return
+		}
+
+		fmt.Fprintf(w, `{"results": [{"Task": "task %d"}]}`, version)
+	})
+
+	defer cleanup()
+
+	ctx := context.Background()
+	cache := &DiskCache{Dir: t.TempDir()}
+	c := cacheClient(url, cache)
+
+	list := func(c *Client) string {
+		t.Helper()
+
+		items, err := c.List(ctx)
+		if err != nil {
+			t.Fatalf("Expected no error, but got: %q instead", err)
+		}
+
+		return items[0].Task
+	}
+
+	testCases := []struct {
+		name                string
+		client              *Client
+		change              func()
+		expectedTask        string
+		expectedNotModified int
+	}{
+		{name: "Miss", client: c, expectedTask: "task 1"},
+		{name: "NotModified", client: c, expectedTask: "task 1", expectedNotModified: 1},
+		{name: "NotModifiedAgain", client: c, expectedTask: "task 1", expectedNotModified: 2},
+		{
+			name:                "Modified",
+			client:              c,
+			change:              func() { version = 2 },
+			expectedTask:        "task 2",
+			expectedNotModified: 2,
+		},
+		{name: "NewVersionStored", client: c, expectedTask: "task 2", expectedNotModified: 3},
+		{
+			name: "OtherCredentials",
+			client: func() *Client {
+				other := cacheClient(url, cache)
+				other.Auth = BearerToken("other")
+
+				return other
+			}(),
+			expectedTask:        "task 2",
+			expectedNotModified: 3,
+		},
+		{
+			name:                "NoValidators",
+			client:              cacheClient(url, cache),
+			change:              func() { version, validators = 3, false },
+			expectedTask:        "task 3",
+			expectedNotModified: 3,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.change != nil {
+				tc.change()
+			}
+
+			if task := list(tc.client); task != tc.expectedTask {
+				t.Errorf("Expected task: %q, but got: %q instead", tc.expectedTask, task)
+			}
+
+			if notModified != tc.expectedNotModified {
+				t.Errorf(
+					"Expected %d 304 responses, but got: %d instead",
+					tc.expectedNotModified,
+					notModified,
+				)
+			}
+		})
+	}
+
+	stats, err := cache.Stats()
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %q instead", err)
+	}
+
+	// The entry of the other credentials is left, as the server stopped
+	// sending validators after it was stored.
+	if stats.Entries != 1 || stats.Hits != 0 || stats.Size == 0 {
+		t.Errorf("Expected 1 entry and no hits, but got: %+v instead", stats)
+	}
+
+	n, err := cache.Clear()
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %q instead", err)
+	}
+
+	if n != 1 {
+		t.Errorf("Expected 1 entry cleared, but got: %d instead", n)
+	}
+
+	if stats, _ := cache.Stats(); stats.Entries != 0 {
+		t.Errorf("Expected an empty cache, but got: %+v instead", stats)
+	}
+}
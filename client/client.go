@@ -0,0 +1,364 @@
+// Package client provides a Go client for the todo_list_api server.
+//
+// It is used by the todo_list_client command-line tool and can be imported
+// by any Go program that needs to talk to the API directly.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+const (
+	defaultTimeout = 10 * time.Second
+
+	// maxIdleConnsPerHost lets batch operations reuse their connections
+	// instead of opening a new one for most requests.
+	maxIdleConnsPerHost = 16
+)
+
+var (
+	ErrConnection      = errors.New("connection error")
+	ErrNotFound        = errors.New("not found")
+	ErrInvalidResponse = errors.New("invalid response")
+	ErrInvalid         = errors.New("invalid data")
+	ErrUnauthorized    = errors.New("unauthorized")
+	ErrUnsupported     = errors.New("not supported by the server")
+	ErrConflict        = errors.New("conflict")
+)
+
+// Item represents a single todo item as returned by the API.
+type Item struct {
+	// ID and UUID identify the item independently of its place in the
+	// list. Older servers do not send them and leave them empty.
+	ID   int
+	UUID string
+
+	Task        string
+	Done        bool
+	CreatedAt   time.Time
+	CompletedAt time.Time
+
+	// Due is the deadline of the item, if it has one.
+	Due time.Time
+
+	// Priority is high, medium or low, or empty for no priority.
+	Priority string
+	Tags     []string
+
+	// Notes is a free-form, possibly multi-line, description of the item.
+	Notes string
+
+	// Parent is the ID of the item this one is a subtask of, or zero for
+	// top-level items.
+	Parent int
+
+	// Recurrence is the RFC 5545 RRULE of items that repeat, such as
+	// FREQ=WEEKLY;BYDAY=MO.
+	Recurrence string
+
+	// Position is the 1-based index of the item in the full list. Servers
+	// that do not send IDs identify items by their position, so the items
+	// of such servers are always fetched unfiltered, and Find keeps the
+	// positions they had in the full list.
+	Position int `json:"-"`
+}
+
+// Ref returns the number that identifies the item in requests to the API:
+// its ID when the server sent one, or its position otherwise.
+func (i Item) Ref() int {
+	if i.ID != 0 {
+		return i.ID
+	}
+
+	return i.Position
+}
+
+// Response represents the envelope the API wraps its results in.
+type Response struct {
+	Results      []Item `json:"results"`
+	Date         int    `json:"date"`
+	TotalResults int    `json:"total_results"`
+
+	// Next, sent by servers that page their results, is the URL of the
+	// next page or a cursor to request it with.
+	Next string `json:"next,omitempty"`
+}
+
+// Client sends requests to a todo_list_api server rooted at BaseURL.
+type Client struct {
+	BaseURL    string
+	HTTPClient *http.Client
+
+	// ListName, when set, scopes the item requests to the named list at
+	// /lists/{name}/todo instead of the default /todo collection.
+	ListName string
+
+	// Auth, when set, adds credentials to every request.
+	Auth Auth
+}
+
+// New returns a Client for the API rooted at baseURL.
+func New(baseURL string) *Client {
+	return &Client{
+		BaseURL: baseURL,
+		HTTPClient: &http.Client{
+			Transport: NewTransport(),
+			Timeout:   defaultTimeout,
+		},
+	}
+}
+
+// List returns all the todo items, from every page of the collection.
+func (c *Client) List(ctx context.Context) ([]Item, error) {
+	return collect(c.Iter(ctx, Query{}, IterOptions{}))
+}
+
+// Find returns the todo items that match q. The query is sent to the server
+// as URL parameters and applied again to the results, so servers that do not
+// filter on their side return the same items.
+func (c *Client) Find(ctx context.Context, q Query) ([]Item, error) {
+	return collect(c.Iter(ctx, q, IterOptions{}))
+}
+
+// collect returns the items it walks through. An empty collection is
+// reported as ErrNotFound.
+func collect(it *Iterator) ([]Item, error) {
+	var items []Item
+
+	for it.Next() {
+		items = append(items, it.Item())
+	}
+
+	if err := it.Err(); err != nil {
+		return nil, err
+	}
+
+	if it.fetched == 0 {
+		return nil, fmt.Errorf("%w: no results found", ErrNotFound)
+	}
+
+	return items, nil
+}
+
+// Get returns the todo item identified by id.
+func (c *Client) Get(ctx context.Context, id int) (Item, error) {
+	items, err := c.getItems(ctx, c.itemURL(id))
+	if err != nil {
+		return Item{}, err
+	}
+
+	// Items without an ID are identified by the position they were asked
+	// for, not by their place in the response.
+	if items[0].ID == 0 {
+		items[0].Position = id
+	}
+
+	return items[0], nil
+}
+
+// NewItem holds the fields of a todo item to create.
+type NewItem struct {
+	Task       string     `json:"task"`
+	Due        *time.Time `json:"due,omitempty"`
+	Priority   string     `json:"priority,omitempty"`
+	Tags       []string   `json:"tags,omitempty"`
+	Notes      string     `json:"notes,omitempty"`
+	Parent     int        `json:"parent,omitempty"`
+	Recurrence string     `json:"recurrence,omitempty"`
+}
+
+// Add creates a new todo item named task.
+func (c *Client) Add(ctx context.Context, task string) error {
+	return c.AddItem(ctx, NewItem{Task: task})
+}
+
+// AddItem creates a new todo item from item.
+func (c *Client) AddItem(ctx context.Context, item NewItem) error {
+	var body bytes.Buffer
+
+	if err := json.NewEncoder(&body).Encode(item); err != nil {
+		return err
+	}
+
+	return c.sendMutatingRequest(
+		ctx, c.itemsURL(), http.MethodPost, "application/json",
+		http.StatusCreated, &body,
+	)
+}
+
+// ItemUpdate holds the changes to make to a todo item. Fields left nil are
+// not changed.
+type ItemUpdate struct {
+	Task  *string    `json:"task,omitempty"`
+	Due   *time.Time `json:"due,omitempty"`
+	Notes *string    `json:"notes,omitempty"`
+}
+
+// Update changes the todo item identified by id as described by u, keeping
+// its creation date and completion status.
+func (c *Client) Update(ctx context.Context, id int, u ItemUpdate) error {
+	var body bytes.Buffer
+
+	if err := json.NewEncoder(&body).Encode(u); err != nil {
+		return err
+	}
+
+	return c.sendMutatingRequest(
+		ctx, c.itemURL(id), http.MethodPatch, "application/json",
+		http.StatusNoContent, &body,
+	)
+}
+
+// Complete marks the todo item identified by id as done.
+func (c *Client) Complete(ctx context.Context, id int) error {
+	u := fmt.Sprintf("%s?complete", c.itemURL(id))
+
+	return c.sendMutatingRequest(
+		ctx, u, http.MethodPatch, "", http.StatusNoContent, nil,
+	)
+}
+
+// Reopen marks the todo item identified by id as pending again, clearing
+// its completion date. Servers without support for reopening items return
+// ErrUnsupported.
+func (c *Client) Reopen(ctx context.Context, id int) error {
+	u := fmt.Sprintf("%s?reopen", c.itemURL(id))
+
+	err := c.sendMutatingRequest(
+		ctx, u, http.MethodPatch, "", http.StatusNoContent, nil,
+	)
+
+	// Servers that do not know the reopen query may not route the request
+	// at all. The item exists, so the 404 is about the endpoint.
+	if errors.Is(err, ErrNotFound) {
+		if _, getErr := c.Get(ctx, id); getErr == nil {
+			return fmt.Errorf("%w: reopening items", ErrUnsupported)
+		}
+	}
+
+	return err
+}
+
+// Delete removes the todo item identified by id.
+func (c *Client) Delete(ctx context.Context, id int) error {
+	return c.sendMutatingRequest(
+		ctx, c.itemURL(id), http.MethodDelete, "", http.StatusNoContent, nil,
+	)
+}
+
+func (c *Client) itemsURL() string {
+	if c.ListName != "" {
+		return fmt.Sprintf("%s/todo", c.listURL(c.ListName))
+	}
+
+	return fmt.Sprintf("%s/todo", c.BaseURL)
+}
+
+func (c *Client) itemURL(id int) string {
+	return fmt.Sprintf("%s/%d", c.itemsURL(), id)
+}
+
+func (c *Client) getItems(ctx context.Context, url string) ([]Item, error) {
+	respData, _, err := c.getPage(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(respData.Results) == 0 {
+		return nil, fmt.Errorf("%w: no results found", ErrNotFound)
+	}
+
+	for i := range respData.Results {
+		respData.Results[i].Position = i + 1
+	}
+
+	return respData.Results, nil
+}
+
+func (c *Client) sendMutatingRequest(
+	ctx context.Context, url, method, contentType string, statusCode int,
+	body io.Reader,
+) error {
+
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return err
+	}
+
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	if key, ok := ctx.Value(idempotencyKey{}).(string); ok && key != "" {
+		req.Header.Set(IdempotencyKeyHeader, key)
+	}
+
+	// Send the request.
+	resp, err := c.do(req)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != statusCode {
+		return c.responseError(resp)
+	}
+
+	return nil
+}
+
+// do sends req with the client credentials and reports transport failures
+// as ErrConnection. A request cancelled through its context returns the
+// context error instead.
+func (c *Client) do(req *http.Request) (*http.Response, error) {
+	if c.Auth != nil {
+		c.Auth.Apply(req)
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		if ctxErr := req.Context().Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+
+		return nil, fmt.Errorf(
+			"%w: %s", ErrConnection, redact(err.Error(), c.Auth),
+		)
+	}
+
+	return resp, nil
+}
+
+// responseError builds an error from an unexpected API response, wrapping
+// ErrNotFound, ErrConflict, ErrUnauthorized, ErrUnsupported or
+// ErrInvalidResponse depending on the status code. The client credentials are redacted from
+// the message.
+func (c *Client) responseError(resp *http.Response) error {
+	msg, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read body: %w", err)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusNotFound:
+		err = ErrNotFound
+	case http.StatusConflict:
+		err = ErrConflict
+	case http.StatusUnauthorized, http.StatusForbidden:
+		err = ErrUnauthorized
+	case http.StatusMethodNotAllowed, http.StatusNotImplemented:
+		err = ErrUnsupported
+	default:
+		err = ErrInvalidResponse
+	}
+
+	return fmt.Errorf("%w: %s", err, redact(string(msg), c.Auth))
+}
@@ -0,0 +1,250 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func mockServer(h http.HandlerFunc) (string, func()) {
+	s := httptest.NewServer(h)
+
+	return s.URL, func() {
+		s.Close()
+	}
+}
+
+func TestClientList(t *testing.T) {
+	url, cleanup := mockServer(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/todo" {
+			t.Fatalf("Expected path: /todo, but got: %s instead", r.URL.Path)
+		}
+
+		w.Write([]byte(`{
+			"results": [
+				{"Task": "task 1", "Done": true},
+				{"Task": "task 2", "Done": false}
+			],
+			"date": 356648847899,
+			"total_results": 2
+		}`))
+	})
+
+	defer cleanup()
+
+	items, err := New(url).List(context.Background())
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %q instead", err)
+	}
+
+	if len(items) != 2 {
+		t.Fatalf("Expected 2 items, but got: %d instead", len(items))
+	}
+
+	if items[0].Task != "task 1" || !items[0].Done {
+		t.Errorf("Unexpected first item: %+v", items[0])
+	}
+}
+
+func TestItemRef(t *testing.T) {
+	url, cleanup := mockServer(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{
+			"results": [
+				{"id": 41, "uuid": "0b7e8a52-3c1f-4d5e-9a6b-1c2d3e4f5a6b", "Task": "task 1"},
+				{"Task": "task 2"}
+			],
+			"date": 356648847899,
+			"total_results": 2
+		}`))
+	})
+
+	defer cleanup()
+
+	items, err := New(url).List(context.Background())
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %q instead", err)
+	}
+
+	if items[0].UUID != "0b7e8a52-3c1f-4d5e-9a6b-1c2d3e4f5a6b" {
+		t.Errorf("Expected the UUID sent by the server, but got: %q", items[0].UUID)
+	}
+
+	if ref := items[0].Ref(); ref != 41 {
+		t.Errorf("Expected the ID sent by the server: 41, but got: %d instead", ref)
+	}
+
+	if ref := items[1].Ref(); ref != 2 {
+		t.Errorf("Expected the position: 2, but got: %d instead", ref)
+	}
+}
+
+func TestClientErrors(t *testing.T) {
+	testCases := []struct {
+		name        string
+		status      int
+		op          func(c *Client) error
+		expectedErr error
+	}{
+		{
+			name:   "GetNotFound",
+			status: http.StatusNotFound,
+			op: func(c *Client) error {
+				_, err := c.Get(context.Background(), 1)
+				return err
+			},
+			expectedErr: ErrNotFound,
+		},
+		{
+			name:        "CompleteNotFound",
+			status:      http.StatusNotFound,
+			op:          func(c *Client) error { return c.Complete(context.Background(), 1) },
+			expectedErr: ErrNotFound,
+		},
+		{
+			name:        "CompleteConflict",
+			status:      http.StatusConflict,
+			op:          func(c *Client) error { return c.Complete(context.Background(), 1) },
+			expectedErr: ErrConflict,
+		},
+		{
+			name:        "DeleteServerError",
+			status:      http.StatusInternalServerError,
+			op:          func(c *Client) error { return c.Delete(context.Background(), 1) },
+			expectedErr: ErrInvalidResponse,
+		},
+		{
+			name:        "AddBadRequest",
+			status:      http.StatusBadRequest,
+			op:          func(c *Client) error { return c.Add(context.Background(), "task") },
+			expectedErr: ErrInvalidResponse,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			url, cleanup := mockServer(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tc.status)
+			})
+
+			defer cleanup()
+
+			err := tc.op(New(url))
+			if !errors.Is(err, tc.expectedErr) {
+				t.Errorf(
+					"Expected error: %q, but got: %q instead",
+					tc.expectedErr,
+					err,
+				)
+			}
+		})
+	}
+}
+
+func TestClientReopen(t *testing.T) {
+	testCases := []struct {
+		name        string
+		patchStatus int
+		getStatus   int
+		expectedErr error
+	}{
+		{name: "Reopened", patchStatus: http.StatusNoContent},
+		{
+			name:        "MethodNotAllowed",
+			patchStatus: http.StatusMethodNotAllowed,
+			expectedErr: ErrUnsupported,
+		},
+		{
+			name:        "NotImplemented",
+			patchStatus: http.StatusNotImplemented,
+			expectedErr: ErrUnsupported,
+		},
+		{
+			name:        "NoEndpoint",
+			patchStatus: http.StatusNotFound,
+			getStatus:   http.StatusOK,
+			expectedErr: ErrUnsupported,
+		},
+		{
+			name:        "NoItem",
+			patchStatus: http.StatusNotFound,
+			getStatus:   http.StatusNotFound,
+			expectedErr: ErrNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			url, cleanup := mockServer(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == http.MethodGet {
+					w.WriteHeader(tc.getStatus)
+					w.Write([]byte(`{"results": [{"Task": "task 1", "Done": true}]}`))
+
+					return
+				}
+
+				if _, ok := r.URL.Query()["reopen"]; !ok || r.Method != http.MethodPatch {
+					t.Fatalf("Unexpected request: %s %s", r.Method, r.URL)
+				}
+
+				w.WriteHeader(tc.patchStatus)
+			})
+
+			defer cleanup()
+
+			err := New(url).Reopen(context.Background(), 1)
+
+			if tc.expectedErr == nil {
+				if err != nil {
+					t.Fatalf("Expected no error, but got: %q instead", err)
+				}
+
+				return
+			}
+
+			if !errors.Is(err, tc.expectedErr) {
+				t.Errorf(
+					"Expected error: %q, but got: %q instead",
+					tc.expectedErr,
+					err,
+				)
+			}
+		})
+	}
+}
+
+func TestClientConnectionError(t *testing.T) {
+	url, cleanup := mockServer(func(w http.ResponseWriter, r *http.Request) {})
+	cleanup()
+
+	if err := New(url).Delete(context.Background(), 1); !errors.Is(err, ErrConnection) {
+		t.Errorf(
+			"Expected error: %q, but got: %q instead",
+			ErrConnection,
+			err,
+		)
+	}
+}
+
+func TestClientCancel(t *testing.T) {
+	unblock := make(chan struct{})
+
+	url, cleanup := mockServer(func(w http.ResponseWriter, r *http.Request) {
+		<-unblock
+	})
+
+	defer cleanup()
+	defer close(unblock)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := New(url).List(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf(
+			"Expected error: %q, but got: %q instead",
+			context.Canceled,
+			err,
+		)
+	}
+}
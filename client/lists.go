@@ -0,0 +1,107 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// ListInfo describes a named todo list.
+type ListInfo struct {
+	Name    string `json:"name"`
+	Total   int    `json:"total"`
+	Pending int    `json:"pending"`
+}
+
+// ListsResponse represents the envelope the API wraps the named lists in.
+type ListsResponse struct {
+	Results []ListInfo `json:"results"`
+}
+
+// Lists returns the named lists with their item counts. Servers without
+// named lists return ErrUnsupported.
+func (c *Client) Lists(ctx context.Context) ([]ListInfo, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.listsURL(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		err := c.responseError(resp)
+
+		if errors.Is(err, ErrNotFound) {
+			return nil, fmt.Errorf("%w: named lists", ErrUnsupported)
+		}
+
+		return nil, err
+	}
+
+	var respData ListsResponse
+
+	if err := json.NewDecoder(resp.Body).Decode(&respData); err != nil {
+		return nil, err
+	}
+
+	return respData.Results, nil
+}
+
+// CreateList creates an empty list called name.
+func (c *Client) CreateList(ctx context.Context, name string) error {
+	return c.sendList(
+		ctx, c.listsURL(), http.MethodPost, http.StatusCreated, name,
+	)
+}
+
+// RenameList renames the list called name to newName, keeping its items.
+func (c *Client) RenameList(ctx context.Context, name, newName string) error {
+	return c.sendList(
+		ctx, c.listURL(name), http.MethodPatch, http.StatusNoContent, newName,
+	)
+}
+
+// DeleteList removes the list called name and all of its items.
+func (c *Client) DeleteList(ctx context.Context, name string) error {
+	return c.sendMutatingRequest(
+		ctx, c.listURL(name), http.MethodDelete, "", http.StatusNoContent, nil,
+	)
+}
+
+// sendList sends a request with a list name as its body.
+func (c *Client) sendList(
+	ctx context.Context, url, method string, statusCode int, name string,
+) error {
+	var body bytes.Buffer
+
+	list := struct {
+		Name string `json:"name"`
+	}{
+		Name: name,
+	}
+
+	if err := json.NewEncoder(&body).Encode(list); err != nil {
+		return err
+	}
+
+	return c.sendMutatingRequest(
+		ctx, url, method, "application/json", statusCode, &body,
+	)
+}
+
+func (c *Client) listsURL() string {
+	return fmt.Sprintf("%s/lists", c.BaseURL)
+}
+
+func (c *Client) listURL(name string) string {
+	return fmt.Sprintf("%s/%s", c.listsURL(), url.PathEscape(name))
+}
@@ -0,0 +1,151 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestClientListName(t *testing.T) {
+	var paths []string
+
+	url, cleanup := mockServer(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.Method+" "+r.URL.EscapedPath())
+
+		switch r.Method {
+		case http.MethodGet:
+			w.Write([]byte(`{"results": [{"Task": "task 1"}]}`))
+		case http.MethodPost:
+			w.WriteHeader(http.StatusCreated)
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	})
+
+	defer cleanup()
+
+	ctx := context.Background()
+
+	c := New(url)
+	c.ListName = "side project"
+
+	if _, err := c.List(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := c.Get(ctx, 3); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.Add(ctx, "task"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.Complete(ctx, 3); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		"GET /lists/side%20project/todo",
+		"GET /lists/side%20project/todo/3",
+		"POST /lists/side%20project/todo",
+		"PATCH /lists/side%20project/todo/3",
+	}
+
+	if !reflect.DeepEqual(expected, paths) {
+		t.Errorf("Expected requests: %q, but got: %q instead", expected, paths)
+	}
+}
+
+func TestClientLists(t *testing.T) {
+	type request struct {
+		method, path, name string
+	}
+
+	var requests []request
+
+	url, cleanup := mockServer(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Name string `json:"name"`
+		}
+
+		if r.Body != http.NoBody {
+			json.NewDecoder(r.Body).Decode(&body)
+		}
+
+		requests = append(requests, request{r.Method, r.URL.Path, body.Name})
+
+		switch r.Method {
+		case http.MethodGet:
+			w.Write([]byte(`{"results": [
+				{"name": "home", "total": 3, "pending": 1},
+				{"name": "work", "total": 5, "pending": 5}
+			]}`))
+		case http.MethodPost:
+			w.WriteHeader(http.StatusCreated)
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	})
+
+	defer cleanup()
+
+	ctx := context.Background()
+	c := New(url)
+
+	lists, err := c.Lists(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedLists := []ListInfo{
+		{Name: "home", Total: 3, Pending: 1},
+		{Name: "work", Total: 5, Pending: 5},
+	}
+
+	if !reflect.DeepEqual(expectedLists, lists) {
+		t.Errorf("Expected lists: %+v, but got: %+v instead", expectedLists, lists)
+	}
+
+	if err := c.CreateList(ctx, "garden"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.RenameList(ctx, "garden", "yard"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.DeleteList(ctx, "yard"); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []request{
+		{http.MethodGet, "/lists", ""},
+		{http.MethodPost, "/lists", "garden"},
+		{http.MethodPatch, "/lists/garden", "yard"},
+		{http.MethodDelete, "/lists/yard", ""},
+	}
+
+	if !reflect.DeepEqual(expected, requests) {
+		t.Errorf("Expected requests: %+v, but got: %+v instead", expected, requests)
+	}
+}
+
+func TestClientListsUnsupported(t *testing.T) {
+	url, cleanup := mockServer(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+
+	defer cleanup()
+
+	if _, err := New(url).Lists(context.Background()); !errors.Is(err, ErrUnsupported) {
+		t.Errorf(
+			"Expected error: %q, but got: %q instead",
+			ErrUnsupported,
+			err,
+		)
+	}
+}
@@ -0,0 +1,278 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// IterOptions narrows down the items an Iterator walks through. The zero
+// IterOptions walks through every page.
+type IterOptions struct {
+	// Page, when set, restricts the iteration to the items of the given
+	// page, counted from 1. The pages before it are still fetched to find
+	// it, as servers paging with cursors cannot jump to a page.
+	Page int
+
+	// Limit, when set, stops the iteration after that many items, without
+	// fetching the pages after the one holding the last of them.
+	Limit int
+}
+
+// Iterator walks through the items of a collection, fetching the pages the
+// server splits it in one at a time, so that only one page is held in
+// memory. Paging is detected from a Link header with a rel="next" URL, a
+// next field in the response, taken as a URL or as a cursor, or a
+// total_results field larger than the number of items received so far. Use
+// it like a bufio.Scanner:
+//
+//	it := c.Iter(ctx, Query{}, IterOptions{})
+//	for it.Next() {
+//		fmt.Println(it.Item().Task)
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+type Iterator struct {
+	c    *Client
+	ctx  context.Context
+	q    Query
+	opts IterOptions
+
+	// url is the URL of the next page, or empty after the last one, and
+	// unfiltered the URL of the first page without the query, set while
+	// the query is sent to the server.
+	url        string
+	unfiltered string
+	page       int
+	items      []Item
+
+	// fetched counts the items received from the server, matching q or
+	// not, and returned the items handed out by Item.
+	fetched  int
+	returned int
+	total    int
+
+	item Item
+	err  error
+}
+
+// Iter returns an Iterator over the todo items that match q. Like Find, it
+// sends q to the server and applies it again to the items received.
+//
+// Servers that do not send IDs identify items by their position in the full
+// list, which a server filtering on its side would not number. When the
+// first page of such a server comes without IDs, the iteration starts over
+// without sending q, and the items are only filtered by the client.
+func (c *Client) Iter(ctx context.Context, q Query, opts IterOptions) *Iterator {
+	it := &Iterator{c: c, ctx: ctx, q: q, opts: opts, url: c.itemsURL()}
+
+	if v := q.Values(); len(v) > 0 {
+		it.unfiltered = it.url
+		it.url = fmt.Sprintf("%s?%s", it.url, v.Encode())
+	}
+
+	return it
+}
+
+// Next advances to the next item, fetching the next page when needed. It
+// returns false when there are no items left or a request failed.
+func (it *Iterator) Next() bool {
+	for {
+		if it.err != nil || (it.opts.Limit > 0 && it.returned >= it.opts.Limit) {
+			return false
+		}
+
+		if len(it.items) == 0 {
+			if !it.fetch() {
+				return false
+			}
+
+			continue
+		}
+
+		item := it.items[0]
+		it.items = it.items[1:]
+
+		if it.q.Match(item) {
+			it.item = item
+			it.returned++
+
+			return true
+		}
+	}
+}
+
+// Item returns the item Next advanced to.
+func (it *Iterator) Item() Item {
+	return it.item
+}
+
+// Err returns the error that stopped the iteration, if any.
+func (it *Iterator) Err() error {
+	return it.err
+}
+
+// Total returns the total_results the server sent with the last page, or
+// zero if it sent none.
+func (it *Iterator) Total() int {
+	return it.total
+}
+
+// More reports whether the server has items the iteration stopped before,
+// because of IterOptions or an error.
+func (it *Iterator) More() bool {
+	return len(it.items) > 0 || it.url != ""
+}
+
+// fetch loads the next page to take items from, skipping the pages before
+// IterOptions.Page. It returns false when there is none.
+func (it *Iterator) fetch() bool {
+	for {
+		if it.url == "" || (it.opts.Page > 0 && it.page >= it.opts.Page) {
+			return false
+		}
+
+		current := it.url
+
+		resp, link, err := it.c.getPage(it.ctx, current)
+		if err != nil {
+			it.err = err
+
+			return false
+		}
+
+		if it.unfiltered != "" && len(resp.Results) > 0 && resp.Results[0].ID == 0 {
+			it.url, it.unfiltered = it.unfiltered, ""
+
+			continue
+		}
+
+		it.unfiltered = ""
+		it.page++
+
+		if resp.TotalResults > 0 {
+			it.total = resp.TotalResults
+		}
+
+		// Positions are counted from the start of the collection.
+		for i := range resp.Results {
+			resp.Results[i].Position = it.fetched + i + 1
+		}
+
+		it.fetched += len(resp.Results)
+		it.url = it.nextURL(current, link, resp)
+
+		if it.opts.Page == 0 || it.page == it.opts.Page {
+			it.items = resp.Results
+
+			return true
+		}
+	}
+}
+
+// nextURL returns the URL of the page after the one fetched from current,
+// or an empty string if it was the last one.
+func (it *Iterator) nextURL(current, link string, resp Response) string {
+	if len(resp.Results) == 0 {
+		return ""
+	}
+
+	u, err := url.Parse(current)
+	if err != nil {
+		return ""
+	}
+
+	switch {
+	case link != "":
+		u, err = u.Parse(link)
+		if err != nil {
+			return ""
+		}
+	case strings.Contains(resp.Next, "/"):
+		u, err = u.Parse(resp.Next)
+		if err != nil {
+			return ""
+		}
+	case resp.Next != "":
+		v := u.Query()
+		v.Set("cursor", resp.Next)
+		u.RawQuery = v.Encode()
+	case resp.TotalResults > it.fetched:
+		v := u.Query()
+		v.Set("page", strconv.Itoa(it.page+1))
+		u.RawQuery = v.Encode()
+	default:
+		return ""
+	}
+
+	// A server pointing back at the same page would never let the
+	// iteration end.
+	if u.String() == current {
+		return ""
+	}
+
+	return u.String()
+}
+
+// getPage fetches a page of items from url, along with the URL of the next
+// page given in its Link header, if any.
+func (c *Client) getPage(ctx context.Context, url string) (Response, string, error) {
+	var page Response
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return page, "", err
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return page, "", err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return page, "", c.responseError(resp)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&page); err != nil {
+		return page, "", err
+	}
+
+	return page, nextLink(resp.Header.Values("Link")), nil
+}
+
+// nextLink returns the URL of the rel="next" link of the Link headers in
+// values, such as `<https://api.example.com/todo?page=2>; rel="next"`.
+func nextLink(values []string) string {
+	for _, v := range values {
+		for _, link := range strings.Split(v, ",") {
+			parts := strings.Split(link, ";")
+
+			target := strings.TrimSpace(parts[0])
+			if !strings.HasPrefix(target, "<") || !strings.HasSuffix(target, ">") {
+				continue
+			}
+
+			for _, param := range parts[1:] {
+				key, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+				if !strings.EqualFold(key, "rel") {
+					continue
+				}
+
+				for _, rel := range strings.Fields(strings.Trim(value, `"`)) {
+					if strings.EqualFold(rel, "next") {
+						return target[1 : len(target)-1]
+					}
+				}
+			}
+		}
+	}
+
+	return ""
+}
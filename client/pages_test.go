@@ -0,0 +1,298 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+// pagedServer serves the tasks in pages of two items, telling the client
+// about the next page as described by scheme. It records the requested URLs
+// in requests.
+func pagedServer(t *testing.T, scheme string, tasks []string, requests *[]string) (string, func()) {
+	t.Helper()
+
+	const perPage = 2
+
+	return mockServer(func(w http.ResponseWriter, r *http.Request) {
+		*requests = append(*requests, r.URL.RequestURI())
+
+		page := 1
+
+		switch scheme {
+		case "total", "link", "next":
+			if v := r.URL.Query().Get("page"); v != "" {
+				page, _ = strconv.Atoi(v)
+			}
+		case "cursor":
+			if v := r.URL.Query().Get("cursor"); v != "" {
+				page, _ = strconv.Atoi(v[len("c"):])
+			}
+		}
+
+		start := (page - 1) * perPage
+		if start > len(tasks) {
+			start = len(tasks)
+		}
+
+		end := start + perPage
+		if end > len(tasks) || scheme == "none" {
+			end = len(tasks)
+		}
+
+		resp := Response{}
+
+		for i, task := range tasks[start:end] {
+			resp.Results = append(resp.Results, Item{ID: start + i + 1, Task: task})
+		}
+
+		last := end >= len(tasks)
+
+		switch scheme {
+		case "total":
+			resp.TotalResults = len(tasks)
+		case "link":
+			if !last {
+				w.Header().Add("Link", `</todo?page=1>; rel="first", `+
+					`</todo?page=`+strconv.Itoa(page+1)+`>; rel="next"`)
+			}
+		case "next":
+			if !last {
+				resp.Next = "/todo?page=" + strconv.Itoa(page+1)
+			}
+		case "cursor":
+			if !last {
+				resp.Next = "c" + strconv.Itoa(page+1)
+			}
+		case "none":
+			resp.TotalResults = len(resp.Results)
+		}
+
+		if err := json.NewEncoder(w).Encode(resp); err != nil {
+			t.Error(err)
+		}
+	})
+}
+
+func TestIterator(t *testing.T) {
+	tasks := []string{"task 1", "task 2", "task 3", "task 4", "task 5"}
+
+	testCases := []struct {
+		name             string
+		scheme           string
+		q                Query
+		opts             IterOptions
+		expectedTasks    []string
+		expectedRequests []string
+		expectedMore     bool
+	}{
+		{
+			name:             "TotalResults",
+			scheme:           "total",
+			expectedTasks:    tasks,
+			expectedRequests: []string{"/todo", "/todo?page=2", "/todo?page=3"},
+		},
+		{
+			name:             "LinkHeader",
+			scheme:           "link",
+			expectedTasks:    tasks,
+			expectedRequests: []string{"/todo", "/todo?page=2", "/todo?page=3"},
+		},
+		{
+			name:             "NextURL",
+			scheme:           "next",
+			expectedTasks:    tasks,
+			expectedRequests: []string{"/todo", "/todo?page=2", "/todo?page=3"},
+		},
+		{
+			name:             "NextCursor",
+			scheme:           "cursor",
+			expectedTasks:    tasks,
+			expectedRequests: []string{"/todo", "/todo?cursor=c2", "/todo?cursor=c3"},
+		},
+		{
+			name:             "NotPaged",
+			scheme:           "none",
+			expectedTasks:    tasks,
+			expectedRequests: []string{"/todo"},
+		},
+		{
+			name:             "Page",
+			scheme:           "link",
+			opts:             IterOptions{Page: 2},
+			expectedTasks:    []string{"task 3", "task 4"},
+			expectedRequests: []string{"/todo", "/todo?page=2"},
+			expectedMore:     true,
+		},
+		{
+			name:             "PageAfterLast",
+			scheme:           "total",
+			opts:             IterOptions{Page: 4},
+			expectedRequests: []string{"/todo", "/todo?page=2", "/todo?page=3"},
+		},
+		{
+			name:             "Limit",
+			scheme:           "cursor",
+			opts:             IterOptions{Limit: 3},
+			expectedTasks:    []string{"task 1", "task 2", "task 3"},
+			expectedRequests: []string{"/todo", "/todo?cursor=c2"},
+			expectedMore:     true,
+		},
+		{
+			name:             "Query",
+			scheme:           "total",
+			q:                Query{Search: "5"},
+			expectedTasks:    []string{"task 5"},
+			expectedRequests: []string{"/todo?q=5", "/todo?page=2&q=5", "/todo?page=3&q=5"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var requests []string
+
+			url, cleanup := pagedServer(t, tc.scheme, tasks, &requests)
+			defer cleanup()
+
+			it := New(url).Iter(context.Background(), tc.q, tc.opts)
+
+			var got []string
+
+			for it.Next() {
+				item := it.Item()
+
+				// Positions continue from one page to the next.
+				if item.Position != item.ID {
+					t.Errorf("Expected position: %d, but got: %d instead", item.ID, item.Position)
+				}
+
+				got = append(got, item.Task)
+			}
+
+			if err := it.Err(); err != nil {
+				t.Fatalf("Expected no error, but got: %q instead", err)
+			}
+
+			if !reflect.DeepEqual(tc.expectedTasks, got) {
+				t.Errorf("Expected tasks: %q, but got: %q instead", tc.expectedTasks, got)
+			}
+
+			if !reflect.DeepEqual(tc.expectedRequests, requests) {
+				t.Errorf("Expected requests: %q, but got: %q instead", tc.expectedRequests, requests)
+			}
+
+			if it.More() != tc.expectedMore {
+				t.Errorf("Expected more: %t, but got: %t instead", tc.expectedMore, it.More())
+			}
+		})
+	}
+}
+
+func TestIteratorFilteringWithoutIDs(t *testing.T) {
+	var requests []string
+
+	tasks := []Item{{Task: "write report", Done: true}, {Task: "call mum"}, {Task: "water plants"}}
+
+	// The server filters on its side, but does not send IDs, so the
+	// positions of the items it returns are not those of the full list.
+	url, cleanup := mockServer(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.RequestURI())
+
+		resp := Response{}
+
+		for _, i := range tasks {
+			if done := r.URL.Query().Get("done"); done == "" || done == strconv.FormatBool(i.Done) {
+				resp.Results = append(resp.Results, i)
+			}
+		}
+
+		if err := json.NewEncoder(w).Encode(resp); err != nil {
+			t.Error(err)
+		}
+	})
+
+	defer cleanup()
+
+	pending := false
+
+	items, err := New(url).Find(context.Background(), Query{Done: &pending})
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %q instead", err)
+	}
+
+	var positions []int
+
+	for _, i := range items {
+		positions = append(positions, i.Ref())
+	}
+
+	if expected := []int{2, 3}; !reflect.DeepEqual(expected, positions) {
+		t.Errorf("Expected positions: %v, but got: %v instead", expected, positions)
+	}
+
+	if expected := []string{"/todo?done=false", "/todo"}; !reflect.DeepEqual(expected, requests) {
+		t.Errorf("Expected requests: %q, but got: %q instead", expected, requests)
+	}
+}
+
+func TestClientListPaged(t *testing.T) {
+	var requests []string
+
+	url, cleanup := pagedServer(t, "total", []string{"task 1", "task 2", "task 3"}, &requests)
+	defer cleanup()
+
+	items, err := New(url).List(context.Background())
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %q instead", err)
+	}
+
+	if len(items) != 3 || len(requests) != 2 {
+		t.Errorf("Expected 3 items in 2 requests, but got: %d in %d instead", len(items), len(requests))
+	}
+}
+
+func TestNextLink(t *testing.T) {
+	testCases := []struct {
+		name     string
+		values   []string
+		expected string
+	}{
+		{name: "None"},
+		{
+			name:     "Next",
+			values:   []string{`<https://api.example.com/todo?page=2>; rel="next"`},
+			expected: "https://api.example.com/todo?page=2",
+		},
+		{
+			name: "Several",
+			values: []string{
+				`<https://api.example.com/todo?page=1>; rel="prev first",` +
+					` <https://api.example.com/todo?page=3>; rel="next"`,
+			},
+			expected: "https://api.example.com/todo?page=3",
+		},
+		{
+			name: "SeveralHeaders",
+			values: []string{
+				`</todo?page=9>; rel=last`,
+				`</todo?page=2>; title="more"; REL=next`,
+			},
+			expected: "/todo?page=2",
+		},
+		{
+			name:   "NoNext",
+			values: []string{`<https://api.example.com/todo?page=1>; rel="prev"`},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := nextLink(tc.values); got != tc.expected {
+				t.Errorf("Expected link: %q, but got: %q instead", tc.expected, got)
+			}
+		})
+	}
+}
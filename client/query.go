@@ -0,0 +1,140 @@
+package client
+
+import (
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Query narrows down the items returned by Client.Find. The zero Query
+// matches every item.
+type Query struct {
+	// Done, when set, selects only completed or only pending items.
+	Done *bool
+
+	// Search selects items whose task contains the given text, ignoring
+	// case. Pattern, when set, is used instead of Search.
+	Search  string
+	Pattern *regexp.Regexp
+
+	CreatedAfter   time.Time
+	CreatedBefore  time.Time
+	CompletedSince time.Time
+
+	// DueBefore selects items with a deadline before the given time.
+	DueBefore time.Time
+
+	// Tags selects items that have all of the given tags, ignoring case.
+	Tags []string
+
+	// Priority selects items with the given priority.
+	Priority string
+
+	// Parent selects the subtasks of the item with the given ID.
+	Parent int
+}
+
+// IsZero reports whether q matches every item.
+func (q Query) IsZero() bool {
+	return q.Pattern == nil && len(q.Values()) == 0
+}
+
+// Values encodes q as the URL query parameters understood by servers that
+// filter on their side. Regular expressions are only applied by the client.
+func (q Query) Values() url.Values {
+	v := url.Values{}
+
+	if q.Done != nil {
+		v.Set("done", strconv.FormatBool(*q.Done))
+	}
+
+	if q.Search != "" && q.Pattern == nil {
+		v.Set("q", q.Search)
+	}
+
+	setTime := func(key string, t time.Time) {
+		if !t.IsZero() {
+			v.Set(key, t.Format(time.RFC3339))
+		}
+	}
+
+	setTime("created_after", q.CreatedAfter)
+	setTime("created_before", q.CreatedBefore)
+	setTime("completed_since", q.CompletedSince)
+	setTime("due_before", q.DueBefore)
+
+	for _, tag := range q.Tags {
+		v.Add("tag", tag)
+	}
+
+	if q.Priority != "" {
+		v.Set("priority", q.Priority)
+	}
+
+	if q.Parent != 0 {
+		v.Set("parent", strconv.Itoa(q.Parent))
+	}
+
+	return v
+}
+
+// Match reports whether i satisfies every condition in q.
+func (q Query) Match(i Item) bool {
+	if q.Done != nil && i.Done != *q.Done {
+		return false
+	}
+
+	if q.Pattern != nil {
+		if !q.Pattern.MatchString(i.Task) {
+			return false
+		}
+	} else if q.Search != "" &&
+		!strings.Contains(strings.ToLower(i.Task), strings.ToLower(q.Search)) {
+		return false
+	}
+
+	if !q.CreatedAfter.IsZero() && !i.CreatedAt.After(q.CreatedAfter) {
+		return false
+	}
+
+	if !q.CreatedBefore.IsZero() && !i.CreatedAt.Before(q.CreatedBefore) {
+		return false
+	}
+
+	if !q.CompletedSince.IsZero() &&
+		(!i.Done || i.CompletedAt.Before(q.CompletedSince)) {
+		return false
+	}
+
+	if !q.DueBefore.IsZero() && (i.Due.IsZero() || !i.Due.Before(q.DueBefore)) {
+		return false
+	}
+
+	if q.Priority != "" && !strings.EqualFold(i.Priority, q.Priority) {
+		return false
+	}
+
+	if q.Parent != 0 && i.Parent != q.Parent {
+		return false
+	}
+
+	for _, tag := range q.Tags {
+		if !hasTag(i, tag) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func hasTag(i Item, tag string) bool {
+	for _, t := range i.Tags {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+
+	return false
+}
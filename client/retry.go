@@ -0,0 +1,194 @@
+package client
+
+import (
+	"context"
+	"io"
+	"math"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// IdempotencyKeyHeader is the header that marks a non-idempotent request,
+// such as a POST, as safe to retry.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+type idempotencyKey struct{}
+
+// WithIdempotencyKey returns a copy of ctx that makes the client send key in
+// the Idempotency-Key header of its mutating requests, which allows
+// RetryTransport to retry them.
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKey{}, key)
+}
+
+// RetryPolicy controls how RetryTransport retries failed requests.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts for a request,
+	// including the first one. Values below 2 disable retries.
+	MaxAttempts int
+
+	// BaseDelay is the wait before the first retry. It doubles with every
+	// further attempt.
+	BaseDelay time.Duration
+
+	// MaxDelay caps every wait, including the ones a server asks for in a
+	// Retry-After header. Zero means no cap.
+	MaxDelay time.Duration
+
+	// Jitter is the fraction, between 0 and 1, of every wait that is
+	// randomized so that clients do not retry in lockstep.
+	Jitter float64
+}
+
+// RetryTransport is an http.RoundTripper that retries requests failing with
+// a connection error or a transient status code, waiting with exponential
+// backoff in between. GET, HEAD, OPTIONS, PUT and DELETE requests are
+// retried; other methods only when they carry an Idempotency-Key header.
+type RetryTransport struct {
+	Base   http.RoundTripper
+	Policy RetryPolicy
+}
+
+// retryStatus holds the status codes that are worth retrying.
+var retryStatus = map[int]bool{
+	http.StatusTooManyRequests:    true,
+	http.StatusBadGateway:         true,
+	http.StatusServiceUnavailable: true,
+	http.StatusGatewayTimeout:     true,
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *RetryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	if !t.canRetry(req) {
+		return base.RoundTrip(req)
+	}
+
+	for attempt := 1; ; attempt++ {
+		r := req
+
+		// Retries send a copy of the request with a fresh body, as a
+		// RoundTripper must not modify the request it is given.
+		if attempt > 1 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+
+			r = req.Clone(req.Context())
+			r.Body = body
+		}
+
+		resp, err := base.RoundTrip(r)
+
+		if attempt >= t.Policy.MaxAttempts || !shouldRetry(req, resp, err) {
+			return resp, err
+		}
+
+		wait := t.backoff(attempt)
+
+		if resp != nil {
+			if d, ok := retryAfter(resp); ok {
+				wait = t.capDelay(d)
+			}
+
+			// Drain the body so that the connection can be reused.
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		if err := sleep(req.Context(), wait); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (t *RetryTransport) canRetry(req *http.Request) bool {
+	if t.Policy.MaxAttempts < 2 {
+		return false
+	}
+
+	// A body that cannot be read again cannot be sent again.
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return false
+	}
+
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions,
+		http.MethodPut, http.MethodDelete:
+		return true
+	}
+
+	return req.Header.Get(IdempotencyKeyHeader) != ""
+}
+
+func shouldRetry(req *http.Request, resp *http.Response, err error) bool {
+	if err != nil {
+		return req.Context().Err() == nil
+	}
+
+	return retryStatus[resp.StatusCode]
+}
+
+// backoff returns the wait before the retry following the given attempt.
+func (t *RetryTransport) backoff(attempt int) time.Duration {
+	d := t.capDelay(time.Duration(
+		float64(t.Policy.BaseDelay) * math.Pow(2, float64(attempt-1)),
+	))
+
+	if t.Policy.Jitter > 0 {
+		d -= time.Duration(rand.Float64() * t.Policy.Jitter * float64(d))
+	}
+
+	return d
+}
+
+func (t *RetryTransport) capDelay(d time.Duration) time.Duration {
+	if t.Policy.MaxDelay > 0 && (d > t.Policy.MaxDelay || d < 0) {
+		return t.Policy.MaxDelay
+	}
+
+	return d
+}
+
+// retryAfter parses the Retry-After header of resp, given either in seconds
+// or as an HTTP date.
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+	v := resp.Header.Get("Retry-After")
+	if v == "" {
+		return 0, false
+	}
+
+	if secs, err := strconv.Atoi(v); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second, true
+	}
+
+	if at, err := http.ParseTime(v); err == nil {
+		d := time.Until(at)
+		if d < 0 {
+			d = 0
+		}
+
+		return d, true
+	}
+
+	return 0, false
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
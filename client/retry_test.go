@@ -0,0 +1,181 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func retryClient(url string, attempts int) *Client {
+	c := New(url)
+	c.HTTPClient.Transport = &RetryTransport{
+		Base: c.HTTPClient.Transport,
+		Policy: RetryPolicy{
+			MaxAttempts: attempts,
+			BaseDelay:   time.Millisecond,
+			MaxDelay:    10 * time.Millisecond,
+			Jitter:      0.5,
+		},
+	}
+
+	return c
+}
+
+func TestRetryTransport(t *testing.T) {
+	testCases := []struct {
+		name             string
+		attempts         int
+		failures         int
+		status           int
+		op               func(ctx context.Context, c *Client) error
+		expectedRequests int
+		expectedErr      error
+	}{
+		{
+			name:     "GetRecovers",
+			attempts: 3,
+			failures: 2,
+			status:   http.StatusServiceUnavailable,
+			op: func(ctx context.Context, c *Client) error {
+				_, err := c.Get(ctx, 1)
+				return err
+			},
+			expectedRequests: 3,
+		},
+		{
+			name:     "GetGivesUp",
+			attempts: 3,
+			failures: 5,
+			status:   http.StatusBadGateway,
+			op: func(ctx context.Context, c *Client) error {
+				_, err := c.Get(ctx, 1)
+				return err
+			},
+			expectedRequests: 3,
+			expectedErr:      ErrInvalidResponse,
+		},
+		{
+			name:     "DeleteRecovers",
+			attempts: 3,
+			failures: 1,
+			status:   http.StatusTooManyRequests,
+			op: func(ctx context.Context, c *Client) error {
+				return c.Delete(ctx, 1)
+			},
+			expectedRequests: 2,
+		},
+		{
+			name:     "NotFoundNotRetried",
+			attempts: 3,
+			failures: 5,
+			status:   http.StatusNotFound,
+			op: func(ctx context.Context, c *Client) error {
+				return c.Delete(ctx, 1)
+			},
+			expectedRequests: 1,
+			expectedErr:      ErrNotFound,
+		},
+		{
+			name:     "PostNotRetried",
+			attempts: 3,
+			failures: 1,
+			status:   http.StatusServiceUnavailable,
+			op: func(ctx context.Context, c *Client) error {
+				return c.Add(ctx, "task")
+			},
+			expectedRequests: 1,
+			expectedErr:      ErrInvalidResponse,
+		},
+		{
+			name:     "PostWithIdempotencyKey",
+			attempts: 3,
+			failures: 1,
+			status:   http.StatusServiceUnavailable,
+			op: func(ctx context.Context, c *Client) error {
+				return c.Add(WithIdempotencyKey(ctx, "key-1"), "task")
+			},
+			expectedRequests: 2,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			requests := 0
+
+			url, cleanup := mockServer(func(w http.ResponseWriter, r *http.Request) {
+				requests++
+
+				if requests <= tc.failures {
+					w.Header().Set("Retry-After", "0")
+					w.WriteHeader(tc.status)
+
+					return
+				}
+
+				switch r.Method {
+				case http.MethodPost:
+					w.WriteHeader(http.StatusCreated)
+				case http.MethodGet:
+					w.Write([]byte(`{"results": [{"Task": "task 1"}]}`))
+				default:
+					w.WriteHeader(http.StatusNoContent)
+				}
+			})
+
+			defer cleanup()
+
+			err := tc.op(context.Background(), retryClient(url, tc.attempts))
+
+			if tc.expectedErr != nil {
+				if !errors.Is(err, tc.expectedErr) {
+					t.Errorf(
+						"Expected error: %q, but got: %q instead",
+						tc.expectedErr,
+						err,
+					)
+				}
+			} else if err != nil {
+				t.Errorf("Expected no error, but got: %q instead", err)
+			}
+
+			if requests != tc.expectedRequests {
+				t.Errorf(
+					"Expected %d requests, but got: %d instead",
+					tc.expectedRequests,
+					requests,
+				)
+			}
+		})
+	}
+}
+
+func TestRetryAfterCapped(t *testing.T) {
+	requests := 0
+
+	url, cleanup := mockServer(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+
+		if requests == 1 {
+			w.Header().Set("Retry-After", "3600")
+			w.WriteHeader(http.StatusServiceUnavailable)
+
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	defer cleanup()
+
+	start := time.Now()
+
+	if err := retryClient(url, 2).Delete(context.Background(), 1); err != nil {
+		t.Fatalf("Expected no error, but got: %q instead", err)
+	}
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected the wait to be capped, but it took %s", elapsed)
+	}
+}
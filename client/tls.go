@@ -0,0 +1,89 @@
+package client
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+)
+
+var ErrInvalidTLS = errors.New("invalid TLS configuration")
+
+// TLSOptions configures how a Client verifies the server certificate and
+// which certificate it presents for mutual TLS.
+type TLSOptions struct {
+	// CAFile is a PEM bundle of the certificate authorities trusted in
+	// addition to the system ones.
+	CAFile string
+
+	// CertFile and KeyFile are the PEM encoded client certificate and key.
+	// Both or neither must be set.
+	CertFile string
+	KeyFile  string
+
+	// ServerName overrides the name the server certificate is verified
+	// against.
+	ServerName string
+
+	// InsecureSkipVerify disables the verification of the server
+	// certificate. It leaves the connection open to interception and
+	// should only be used for testing.
+	InsecureSkipVerify bool
+}
+
+// Config builds the tls.Config described by o.
+func (o TLSOptions) Config() (*tls.Config, error) {
+	cfg := &tls.Config{
+		ServerName:         o.ServerName,
+		InsecureSkipVerify: o.InsecureSkipVerify,
+	}
+
+	if o.CAFile != "" {
+		pem, err := os.ReadFile(o.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidTLS, err)
+		}
+
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf(
+				"%w: no certificates found in %s", ErrInvalidTLS, o.CAFile,
+			)
+		}
+
+		cfg.RootCAs = pool
+	}
+
+	if (o.CertFile == "") != (o.KeyFile == "") {
+		return nil, fmt.Errorf(
+			"%w: a client certificate and key must be given together",
+			ErrInvalidTLS,
+		)
+	}
+
+	if o.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(o.CertFile, o.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidTLS, err)
+		}
+
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+
+	return cfg, nil
+}
+
+// NewTransport returns the http.Transport used by New, a copy of the default
+// transport tuned for batch operations.
+func NewTransport() *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConnsPerHost = maxIdleConnsPerHost
+
+	return transport
+}
@@ -0,0 +1,264 @@
+package client
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testCA is a certificate authority generated for a test, able to issue
+// server and client certificates.
+type testCA struct {
+	t    *testing.T
+	dir  string
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pool *x509.CertPool
+	file string
+}
+
+func newTestCA(t *testing.T) *testCA {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "todo test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ca := &testCA{t: t, dir: t.TempDir(), cert: cert, key: key}
+	ca.pool = x509.NewCertPool()
+	ca.pool.AddCert(cert)
+	ca.file = ca.writePEM("ca.pem", "CERTIFICATE", der)
+
+	return ca
+}
+
+func (ca *testCA) writePEM(name, blockType string, der []byte) string {
+	ca.t.Helper()
+
+	path := filepath.Join(ca.dir, name)
+	data := pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		ca.t.Fatal(err)
+	}
+
+	return path
+}
+
+// issue returns a certificate signed by the CA, and the paths of its PEM
+// encoded certificate and key files.
+func (ca *testCA) issue(
+	name string, usage x509.ExtKeyUsage, dnsNames []string, ips []net.IP,
+) (tls.Certificate, string, string) {
+	ca.t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		ca.t.Fatal(err)
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+		DNSNames:     dnsNames,
+		IPAddresses:  ips,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		ca.t.Fatal(err)
+	}
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		ca.t.Fatal(err)
+	}
+
+	certFile := ca.writePEM(name+".pem", "CERTIFICATE", der)
+	keyFile := ca.writePEM(name+"-key.pem", "EC PRIVATE KEY", keyDER)
+
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		ca.t.Fatal(err)
+	}
+
+	return cert, certFile, keyFile
+}
+
+func TestClientTLS(t *testing.T) {
+	ca := newTestCA(t)
+
+	serverCert, _, _ := ca.issue(
+		"server", x509.ExtKeyUsageServerAuth,
+		[]string{"todo.internal"}, []net.IP{net.ParseIP("127.0.0.1")},
+	)
+	namedCert, _, _ := ca.issue(
+		"named", x509.ExtKeyUsageServerAuth, []string{"todo.internal"}, nil,
+	)
+	_, clientCertFile, clientKeyFile := ca.issue(
+		"client", x509.ExtKeyUsageClientAuth, nil, nil,
+	)
+
+	testCases := []struct {
+		name        string
+		serverCert  tls.Certificate
+		mutual      bool
+		opts        TLSOptions
+		expectedErr error
+	}{
+		{
+			name:        "UnknownCA",
+			serverCert:  serverCert,
+			expectedErr: ErrConnection,
+		},
+		{
+			name:       "CustomCA",
+			serverCert: serverCert,
+			opts:       TLSOptions{CAFile: ca.file},
+		},
+		{
+			name:        "ServerNameMismatch",
+			serverCert:  namedCert,
+			opts:        TLSOptions{CAFile: ca.file},
+			expectedErr: ErrConnection,
+		},
+		{
+			name:       "ServerName",
+			serverCert: namedCert,
+			opts:       TLSOptions{CAFile: ca.file, ServerName: "todo.internal"},
+		},
+		{
+			name:        "MutualWithoutClientCert",
+			serverCert:  serverCert,
+			mutual:      true,
+			opts:        TLSOptions{CAFile: ca.file},
+			expectedErr: ErrConnection,
+		},
+		{
+			name:       "Mutual",
+			serverCert: serverCert,
+			mutual:     true,
+			opts: TLSOptions{
+				CAFile:   ca.file,
+				CertFile: clientCertFile,
+				KeyFile:  clientKeyFile,
+			},
+		},
+		{
+			name:       "InsecureSkipVerify",
+			serverCert: namedCert,
+			opts:       TLSOptions{InsecureSkipVerify: true},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := httptest.NewUnstartedServer(http.HandlerFunc(
+				func(w http.ResponseWriter, r *http.Request) {
+					w.WriteHeader(http.StatusNoContent)
+				},
+			))
+
+			s.TLS = &tls.Config{Certificates: []tls.Certificate{tc.serverCert}}
+
+			if tc.mutual {
+				s.TLS.ClientAuth = tls.RequireAndVerifyClientCert
+				s.TLS.ClientCAs = ca.pool
+			}
+
+			s.StartTLS()
+			defer s.Close()
+
+			tlsConfig, err := tc.opts.Config()
+			if err != nil {
+				t.Fatalf("Expected no error, but got: %q instead", err)
+			}
+
+			transport := NewTransport()
+			transport.TLSClientConfig = tlsConfig
+
+			c := New(s.URL)
+			c.HTTPClient.Transport = transport
+
+			err = c.Delete(context.Background(), 1)
+
+			if tc.expectedErr != nil {
+				if !errors.Is(err, tc.expectedErr) {
+					t.Errorf(
+						"Expected error: %q, but got: %q instead",
+						tc.expectedErr,
+						err,
+					)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Errorf("Expected no error, but got: %q instead", err)
+			}
+		})
+	}
+}
+
+func TestTLSOptionsInvalid(t *testing.T) {
+	testCases := []struct {
+		name string
+		opts TLSOptions
+	}{
+		{name: "MissingCA", opts: TLSOptions{CAFile: "missing.pem"}},
+		{name: "CertWithoutKey", opts: TLSOptions{CertFile: "client.pem"}},
+		{name: "KeyWithoutCert", opts: TLSOptions{KeyFile: "client-key.pem"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := tc.opts.Config(); !errors.Is(err, ErrInvalidTLS) {
+				t.Errorf(
+					"Expected error: %q, but got: %q instead",
+					ErrInvalidTLS,
+					err,
+				)
+			}
+		})
+	}
+}
@@ -5,10 +5,25 @@ package cmd
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
+	"time"
+
+	"github.com/mycok/todo_list_client/client"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
 )
 
 func TestListAction(t *testing.T) {
@@ -30,12 +45,12 @@ func TestListAction(t *testing.T) {
 		},
 		{
 			name:        "NoResults",
-			expectedErr: ErrNotFound,
+			expectedErr: client.ErrNotFound,
 			resp:        testResp["noResults"],
 		},
 		{
 			name:        "InvalidURL",
-			expectedErr: ErrConnection,
+			expectedErr: client.ErrConnection,
 			resp:        testResp["noResults"],
 			closeServer: true,
 		},
@@ -57,7 +72,7 @@ func TestListAction(t *testing.T) {
 
 			var outputBuf bytes.Buffer
 
-			err := listAction(&outputBuf, url)
+			err := listAction(context.Background(), &outputBuf, url, textFormatter{}, listOptions{})
 
 			// Handle the error path.
 			if tc.expectedErr != nil {
@@ -118,7 +133,7 @@ func TestViewAction(t *testing.T) {
 		},
 		{
 			name:        "NoFound",
-			expectedErr: ErrNotFound,
+			expectedErr: client.ErrNotFound,
 			resp:        testResp["noResults"],
 			id:          "1",
 		},
@@ -142,7 +157,7 @@ func TestViewAction(t *testing.T) {
 
 			var outputBuf bytes.Buffer
 
-			err := viewAction(&outputBuf, url, tc.id)
+			err := viewAction(context.Background(), &outputBuf, url, tc.id, textFormatter{})
 
 			// Handle the error path.
 			if tc.expectedErr != nil {
@@ -228,6 +243,11 @@ func TestAddAction(t *testing.T) {
 			)
 		}
 
+		// The first attempt carries the key the outbox would replay it with.
+		if r.Header.Get(client.IdempotencyKeyHeader) == "" {
+			t.Errorf("Expected an idempotency key, but got none")
+		}
+
 		w.Header().Set("Content-Type", expectedContentType)
 		w.WriteHeader(testResp["created"].Status)
 		w.Write([]byte(testResp["created"].Body))
@@ -237,7 +257,7 @@ func TestAddAction(t *testing.T) {
 
 	var body bytes.Buffer
 
-	if err := addAction(&body, url, args); err != nil {
+	if err := addAction(context.Background(), &body, url, args, addOptions{}); err != nil {
 		t.Fatalf("Expected no error, but got: %q instead", err)
 	}
 
@@ -258,6 +278,14 @@ func TestCompleteAction(t *testing.T) {
 	arg := "1"
 
 	url, cleanup := mockServer(func(w http.ResponseWriter, r *http.Request) {
+		// The item is fetched, and its subtasks asked for by parent.
+		if r.Method == http.MethodGet {
+			w.WriteHeader(testResp["resultsOne"].Status)
+			w.Write([]byte(testResp["resultsOne"].Body))
+
+			return
+		}
+
 		if r.URL.Path != expectedURLPath {
 			t.Fatalf(
 				"Expected path: %s, but got: %s instead",
@@ -286,7 +314,7 @@ func TestCompleteAction(t *testing.T) {
 
 	var body bytes.Buffer
 
-	if err := completeAction(&body, url, arg); err != nil {
+	if err := completeAction(context.Background(), &body, url, []string{arg}, false); err != nil {
 		t.Fatalf("Expected no error, but got: %q instead", err)
 	}
 
@@ -330,7 +358,7 @@ func TestDeleteAction(t *testing.T) {
 
 	var body bytes.Buffer
 
-	if err := deleteAction(&body, url, arg); err != nil {
+	if err := deleteAction(context.Background(), &body, url, []string{arg}); err != nil {
 		t.Fatalf("Expected no error, but got: %q instead", err)
 	}
 
@@ -342,3 +370,1597 @@ func TestDeleteAction(t *testing.T) {
 		)
 	}
 }
+
+func TestListActionFormats(t *testing.T) {
+	testCases := []struct {
+		name           string
+		format         string
+		expectedOutput string
+	}{
+		{
+			name:   "JSON",
+			format: "json",
+			expectedOutput: `[
+  {
+    "id": 1,
+    "task": "task 1",
+    "done": false,
+    "created_at": "2019-10-28T08:23:38.310097076-04:00"
+  },
+  {
+    "id": 2,
+    "task": "task 2",
+    "done": false,
+    "created_at": "2019-10-28T08:23:38.310097076-04:00"
+  }
+]
+`,
+		},
+		{
+			name:   "YAML",
+			format: "yaml",
+			expectedOutput: `- id: 1
+  task: task 1
+  done: false
+  created_at: 2019-10-28T08:23:38.310097076-04:00
+- id: 2
+  task: task 2
+  done: false
+  created_at: 2019-10-28T08:23:38.310097076-04:00
+`,
+		},
+		{
+			name:   "CSV",
+			format: "csv",
+			expectedOutput: "id,task,done,created_at,completed_at,due,priority,tags,notes,parent,recurrence\n" +
+				"1,task 1,false,2019-10-28T08:23:38-04:00,,,,,,,\n" +
+				"2,task 2,false,2019-10-28T08:23:38-04:00,,,,,,,\n",
+		},
+		{
+			name:   "TSV",
+			format: "tsv",
+			expectedOutput: "id\ttask\tdone\tcreated_at\tcompleted_at\tdue\tpriority\ttags\tnotes\tparent\trecurrence\n" +
+				"1\ttask 1\tfalse\t2019-10-28T08:23:38-04:00\t\t\t\t\t\t\t\n" +
+				"2\ttask 2\tfalse\t2019-10-28T08:23:38-04:00\t\t\t\t\t\t\t\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			url, cleanup := mockServer(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(testResp["resultsMany"].Status)
+				w.Write([]byte(testResp["resultsMany"].Body))
+			})
+
+			defer cleanup()
+
+			f, err := getFormatter(tc.format)
+			if err != nil {
+				t.Fatalf("Expected no error, but got: %q instead", err)
+			}
+
+			var outputBuf bytes.Buffer
+
+			if err := listAction(context.Background(), &outputBuf, url, f, listOptions{}); err != nil {
+				t.Fatalf("Expected no error, but got: %q instead", err)
+			}
+
+			if tc.expectedOutput != outputBuf.String() {
+				t.Errorf(
+					"Expected output: %s, but got: %s instead",
+					tc.expectedOutput,
+					outputBuf.String(),
+				)
+			}
+		})
+	}
+}
+
+func TestGetFormatterInvalid(t *testing.T) {
+	if _, err := getFormatter("xml"); !errors.Is(err, ErrInvalidFormat) {
+		t.Errorf(
+			"Expected error: %q, but got: %q instead",
+			ErrInvalidFormat,
+			err,
+		)
+	}
+}
+
+func TestListActionCustomFormats(t *testing.T) {
+	testCases := []struct {
+		name           string
+		template       string
+		jsonPath       string
+		expectedOutput string
+	}{
+		{
+			name:           "Template",
+			template:       `{{range .}}{{.ID}} {{.Task}}{{"\n"}}{{end}}`,
+			expectedOutput: "1 task 1\n2 task 2\n",
+		},
+		{
+			name:           "JSONPathIDs",
+			jsonPath:       "{[?(@.done==false)].id}",
+			expectedOutput: "1 2\n",
+		},
+		{
+			name:           "JSONPathIndex",
+			jsonPath:       "$[1].task",
+			expectedOutput: "task 2\n",
+		},
+		{
+			name:           "JSONPathRecursive",
+			jsonPath:       "{..task}",
+			expectedOutput: "task 1 task 2\n",
+		},
+		{
+			name:           "JSONPathText",
+			jsonPath:       "first: {[0].task}, last: {[-1].id}",
+			expectedOutput: "first: task 1, last: 2\n",
+		},
+		{
+			name:           "JSONPathRange",
+			jsonPath:       `{range [*]}{.id}{"\t"}{@.task}{"\n"}{end}`,
+			expectedOutput: "1\ttask 1\n2\ttask 2\n",
+		},
+		{
+			name:           "JSONPathRangeRoot",
+			jsonPath:       `{range [?(@.id>1)]}{.task} of {$[*].id}{end}`,
+			expectedOutput: "task 2 of 1 2\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			url, cleanup := mockServer(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(testResp["resultsMany"].Status)
+				w.Write([]byte(testResp["resultsMany"].Body))
+			})
+
+			defer cleanup()
+
+			f, err := resolveFormatter("text", tc.template, tc.jsonPath)
+			if err != nil {
+				t.Fatalf("Expected no error, but got: %q instead", err)
+			}
+
+			var outputBuf bytes.Buffer
+
+			if err := listAction(context.Background(), &outputBuf, url, f, listOptions{}); err != nil {
+				t.Fatalf("Expected no error, but got: %q instead", err)
+			}
+
+			if tc.expectedOutput != outputBuf.String() {
+				t.Errorf(
+					"Expected output: %s, but got: %s instead",
+					tc.expectedOutput,
+					outputBuf.String(),
+				)
+			}
+		})
+	}
+}
+
+func TestResolveFormatterErrors(t *testing.T) {
+	testCases := []struct {
+		name     string
+		template string
+		jsonPath string
+	}{
+		{name: "BadTemplate", template: "{{range .}}"},
+		{name: "BadJSONPath", jsonPath: "{.results[}"},
+		{name: "BadFilter", jsonPath: "{[?(done==false)]}"},
+		{name: "UnterminatedBrace", jsonPath: "{.id"},
+		{name: "RangeWithoutEnd", jsonPath: "{range [*]}{.id}"},
+		{name: "EndWithoutRange", jsonPath: "{.id}{end}"},
+		{name: "BadString", jsonPath: `{"\q"}`},
+		{name: "Both", template: "{{.}}", jsonPath: "{.id}"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := resolveFormatter("text", tc.template, tc.jsonPath)
+			if !errors.Is(err, ErrInvalidFormat) {
+				t.Errorf(
+					"Expected error: %q, but got: %q instead",
+					ErrInvalidFormat,
+					err,
+				)
+			}
+		})
+	}
+}
+
+func TestListActionFilters(t *testing.T) {
+	testCases := []struct {
+		name           string
+		args           []string
+		expectedQuery  string
+		expectedOutput string
+		expectedErr    error
+	}{
+		{
+			name:           "Pending",
+			args:           []string{"--pending"},
+			expectedQuery:  "done=false",
+			expectedOutput: "𝘅  2  fix login           \n𝘅  3  Deploy login service\n",
+		},
+		{
+			name:           "Done",
+			args:           []string{"--done"},
+			expectedQuery:  "done=true",
+			expectedOutput: "✅  1  write report\n",
+		},
+		{
+			name:           "SearchSubstring",
+			args:           []string{"--search", "LOGIN"},
+			expectedQuery:  "q=LOGIN",
+			expectedOutput: "𝘅  2  fix login           \n𝘅  3  Deploy login service\n",
+		},
+		{
+			name:           "SearchRegex",
+			args:           []string{"--search", "/^(fix|write) /"},
+			expectedOutput: "✅  1  write report\n𝘅  2  fix login   \n",
+		},
+		{
+			name:           "CreatedAfter",
+			args:           []string{"--created-after", "2019-10-28T13:00:00Z"},
+			expectedQuery:  "created_after=2019-10-28T13%3A00%3A00Z",
+			expectedOutput: "𝘅  2  fix login\n",
+		},
+		{
+			name: "SortTask",
+			args: []string{"--sort", "task"},
+			expectedOutput: "𝘅  3  Deploy login service\n" +
+				"𝘅  2  fix login           \n" +
+				"✅  1  write report        \n",
+		},
+		{
+			name: "SortCreatedReverse",
+			args: []string{"--sort", "created", "--reverse"},
+			expectedOutput: "𝘅  2  fix login           \n" +
+				"✅  1  write report        \n" +
+				"𝘅  3  Deploy login service\n",
+		},
+		{
+			name:          "NoMatches",
+			args:          []string{"--search", "nothing"},
+			expectedQuery: "q=nothing",
+			expectedErr:   client.ErrNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var queries []string
+
+			url, cleanup := mockServer(func(w http.ResponseWriter, r *http.Request) {
+				queries = append(queries, r.URL.RawQuery)
+
+				w.WriteHeader(testResp["resultsMixed"].Status)
+				w.Write([]byte(testResp["resultsMixed"].Body))
+			})
+
+			defer cleanup()
+
+			// The items have no IDs, so a filtered list is fetched again
+			// unfiltered, to number them by their place in the full list.
+			defer func() {
+				expectedQueries := []string{""}
+				if tc.expectedQuery != "" {
+					expectedQueries = []string{tc.expectedQuery, ""}
+				}
+
+				if !reflect.DeepEqual(expectedQueries, queries) {
+					t.Errorf("Expected queries: %q, but got: %q instead", expectedQueries, queries)
+				}
+			}()
+
+			cmd := &cobra.Command{}
+			cmd.Flags().AddFlagSet(listCmd.Flags())
+
+			if err := cmd.Flags().Parse(tc.args); err != nil {
+				t.Fatal(err)
+			}
+
+			defer cmd.Flags().VisitAll(resetFlag)
+
+			opts, err := listOptionsFromFlags(cmd)
+			if err != nil {
+				t.Fatalf("Expected no error, but got: %q instead", err)
+			}
+
+			var outputBuf bytes.Buffer
+
+			err = listAction(context.Background(), &outputBuf, url, textFormatter{}, opts)
+
+			if tc.expectedErr != nil {
+				if !errors.Is(err, tc.expectedErr) {
+					t.Errorf(
+						"Expected error: %q, but got: %q instead",
+						tc.expectedErr,
+						err,
+					)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Expected no error, but got: %q instead", err)
+			}
+
+			if tc.expectedOutput != outputBuf.String() {
+				t.Errorf(
+					"Expected output: %s, but got: %s instead",
+					tc.expectedOutput,
+					outputBuf.String(),
+				)
+			}
+		})
+	}
+}
+
+func TestListActionPages(t *testing.T) {
+	var notices bytes.Buffer
+
+	stderr = &notices
+	defer func() { stderr = os.Stderr }()
+
+	// The server sends the items in pages of two, with the URL of the next
+	// page in a Link header.
+	url, cleanup := mockServer(func(w http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		if page == 0 {
+			page = 1
+		}
+
+		resp := client.Response{TotalResults: 5}
+
+		for id := 2*page - 1; id <= 2*page && id <= 5; id++ {
+			resp.Results = append(resp.Results, client.Item{ID: id, Task: fmt.Sprintf("task %d", id)})
+		}
+
+		if page < 3 {
+			w.Header().Set("Link", fmt.Sprintf(`</todo?page=%d>; rel="next"`, page+1))
+		}
+
+		json.NewEncoder(w).Encode(resp)
+	})
+
+	defer cleanup()
+
+	testCases := []struct {
+		name           string
+		args           []string
+		expectedOutput string
+		expectedMore   bool
+		expectedErr    error
+	}{
+		{name: "Default", expectedOutput: "1 2 3 4 5\n"},
+		{name: "Page", args: []string{"--page", "2"}, expectedOutput: "3 4\n", expectedMore: true},
+		{name: "LastPage", args: []string{"--page", "3"}, expectedOutput: "5\n"},
+		{name: "Limit", args: []string{"--limit", "3"}, expectedOutput: "1 2 3\n", expectedMore: true},
+		{name: "PageAfterLast", args: []string{"--page", "4"}, expectedErr: client.ErrNotFound},
+		{name: "NegativeLimit", args: []string{"--limit", "-1"}, expectedErr: ErrInvalidFilter},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			notices.Reset()
+
+			cmd := &cobra.Command{}
+			cmd.Flags().AddFlagSet(listCmd.Flags())
+
+			if err := cmd.Flags().Parse(tc.args); err != nil {
+				t.Fatal(err)
+			}
+
+			defer cmd.Flags().VisitAll(resetFlag)
+
+			var outputBuf bytes.Buffer
+
+			opts, err := listOptionsFromFlags(cmd)
+			if err == nil {
+				f := jsonPathFormatter{mustParseJSONPath(t, "{[*].id}")}
+				err = listAction(context.Background(), &outputBuf, url, f, opts)
+			}
+
+			if tc.expectedErr != nil {
+				if !errors.Is(err, tc.expectedErr) {
+					t.Errorf("Expected error: %q, but got: %q instead", tc.expectedErr, err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Expected no error, but got: %q instead", err)
+			}
+
+			if tc.expectedOutput != outputBuf.String() {
+				t.Errorf("Expected output: %q, but got: %q instead", tc.expectedOutput, outputBuf.String())
+			}
+
+			if more := notices.Len() > 0; more != tc.expectedMore {
+				t.Errorf("Expected more items notice: %t, but got: %q instead", tc.expectedMore, notices.String())
+			}
+		})
+	}
+
+	// Unsorted JSON and CSV lists are streamed, and must read the same as
+	// sorted ones, which are written at once.
+	for _, f := range []formatter{jsonFormatter{}, delimitedFormatter{comma: ','}} {
+		var streamed, written bytes.Buffer
+
+		if err := listAction(context.Background(), &streamed, url, f, listOptions{}); err != nil {
+			t.Fatalf("Expected no error, but got: %q instead", err)
+		}
+
+		opts := listOptions{sortBy: "created"}
+
+		if err := listAction(context.Background(), &written, url, f, opts); err != nil {
+			t.Fatalf("Expected no error, but got: %q instead", err)
+		}
+
+		if streamed.String() != written.String() {
+			t.Errorf("Expected output: %q, but got: %q instead", written.String(), streamed.String())
+		}
+	}
+
+	// Streamed lists are cached for offline use like the others, unless
+	// only some of the pages were listed.
+	c, err := newClient(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := offlineStoreFor(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, opts := range []listOptions{{}, {limit: 3}, {page: 2}} {
+		if err := s.saveList(cachedList{}); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := listAction(context.Background(), io.Discard, url, jsonFormatter{}, opts); err != nil {
+			t.Fatalf("Expected no error, but got: %q instead", err)
+		}
+
+		expectedCached := 0
+		if opts.limit == 0 && opts.page == 0 {
+			expectedCached = 5
+		}
+
+		list, _, err := s.loadList()
+		if err != nil {
+			t.Fatalf("Expected no error, but got: %q instead", err)
+		}
+
+		if len(list.Items) != expectedCached || (expectedCached > 0 && list.Items[4].Task != "task 5") {
+			t.Errorf("Expected %d cached items, but got: %+v instead", expectedCached, list.Items)
+		}
+	}
+}
+
+func TestDeleteActionMany(t *testing.T) {
+	expectedOutput := "Item number 5 deleted from the list\n" +
+		"Item number 4 not deleted: not found: 404 - not found\n" +
+		"Item number 1 deleted from the list\n"
+
+	testCases := []struct {
+		name          string
+		ids           bool
+		expectedPaths []string
+	}{
+		// Items without IDs are deleted one at a time, from the last one.
+		{name: "Positions", expectedPaths: []string{"/todo/5", "/todo/4", "/todo/1"}},
+		// Items with IDs are deleted in parallel, in any order.
+		{name: "IDs", ids: true, expectedPaths: []string{"/todo/1", "/todo/4", "/todo/5"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var (
+				mu    sync.Mutex
+				paths []string
+			)
+
+			url, cleanup := mockServer(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == http.MethodGet {
+					item := client.Item{Task: "task 5"}
+					if tc.ids {
+						item.ID = 5
+					}
+
+					json.NewEncoder(w).Encode(client.Response{Results: []client.Item{item}})
+
+					return
+				}
+
+				mu.Lock()
+				paths = append(paths, r.URL.Path)
+				mu.Unlock()
+
+				if r.URL.Path == "/todo/4" {
+					w.WriteHeader(testResp["notFound"].Status)
+					w.Write([]byte(testResp["notFound"].Body))
+
+					return
+				}
+
+				w.WriteHeader(testResp["noContent"].Status)
+			})
+
+			defer cleanup()
+
+			var body bytes.Buffer
+
+			err := deleteAction(context.Background(), &body, url, []string{"1,4-5"})
+			if err == nil {
+				t.Fatal("Expected an error for the failed item, but got none")
+			}
+
+			if tc.ids {
+				sort.Strings(paths)
+			}
+
+			if strings.Join(tc.expectedPaths, " ") != strings.Join(paths, " ") {
+				t.Errorf(
+					"Expected requests: %v, but got: %v instead",
+					tc.expectedPaths,
+					paths,
+				)
+			}
+
+			if expectedOutput != body.String() {
+				t.Errorf(
+					"Expected output: %s, but got: %s instead",
+					expectedOutput,
+					body.String(),
+				)
+			}
+		})
+	}
+}
+
+func TestActionsServerIDs(t *testing.T) {
+	var requests []string
+
+	url, cleanup := mockServer(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/todo":
+			w.WriteHeader(testResp["resultsIDs"].Status)
+			w.Write([]byte(testResp["resultsIDs"].Body))
+		case r.Method == http.MethodGet:
+			w.WriteHeader(testResp["resultsOne"].Status)
+			w.Write([]byte(testResp["resultsOne"].Body))
+		default:
+			w.WriteHeader(testResp["noContent"].Status)
+		}
+	})
+
+	defer cleanup()
+
+	testCases := []struct {
+		name             string
+		action           func(w io.Writer) error
+		expectedRequests []string
+		expectedOutput   string
+		expectedErr      error
+	}{
+		{
+			name: "List",
+			action: func(w io.Writer) error {
+				return listAction(context.Background(), w, url, textFormatter{}, listOptions{})
+			},
+			expectedRequests: []string{"GET /todo"},
+			expectedOutput:   "𝘅  12 task 1\n✅  17 task 2\n",
+		},
+		{
+			name: "ViewID",
+			action: func(w io.Writer) error {
+				return viewAction(context.Background(), w, url, "17", jsonPathFormatter{mustParseJSONPath(t, "{.id}")})
+			},
+			expectedRequests: []string{"GET /todo/17"},
+			expectedOutput:   "17\n",
+		},
+		{
+			name: "ViewPosition",
+			action: func(w io.Writer) error {
+				return viewAction(context.Background(), w, url, "#2", jsonPathFormatter{mustParseJSONPath(t, "{.id}")})
+			},
+			expectedRequests: []string{"GET /todo", "GET /todo/17"},
+			expectedOutput:   "17\n",
+		},
+		{
+			name: "CompletePositions",
+			action: func(w io.Writer) error {
+				return completeAction(context.Background(), w, url, []string{"#1-#2", "17"}, false)
+			},
+			expectedRequests: []string{"GET /todo", "PATCH /todo/12", "PATCH /todo/17"},
+			expectedOutput: "Item number 12 marked as complete\n" +
+				"Item number 17 marked as complete\n",
+		},
+		{
+			name: "DeletePositionOutOfRange",
+			action: func(w io.Writer) error {
+				return deleteAction(context.Background(), w, url, []string{"#3"})
+			},
+			expectedRequests: []string{"GET /todo"},
+			expectedErr:      ErrInvalidID,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			requests = nil
+
+			var body bytes.Buffer
+
+			err := tc.action(&body)
+
+			if tc.expectedErr != nil {
+				if !errors.Is(err, tc.expectedErr) {
+					t.Errorf("Expected error: %q, but got: %q instead", tc.expectedErr, err)
+				}
+			} else if err != nil {
+				t.Fatalf("Expected no error, but got: %q instead", err)
+			}
+
+			if strings.Join(tc.expectedRequests, ", ") != strings.Join(requests, ", ") {
+				t.Errorf(
+					"Expected requests: %v, but got: %v instead",
+					tc.expectedRequests,
+					requests,
+				)
+			}
+
+			if tc.expectedOutput != body.String() {
+				t.Errorf(
+					"Expected output: %q, but got: %q instead",
+					tc.expectedOutput,
+					body.String(),
+				)
+			}
+		})
+	}
+}
+
+func mustParseJSONPath(t *testing.T, expr string) *jsonPath {
+	t.Helper()
+
+	p, err := parseJSONPath(expr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return p
+}
+
+func TestEditAction(t *testing.T) {
+	testCases := []struct {
+		name          string
+		args          []string
+		due           time.Time
+		editor        func(text string) (string, error)
+		patchStatus   int
+		expectedTask  string
+		expectedNotes string
+		expectedDue   time.Time
+		expOut        string
+		expErr        error
+	}{
+		{
+			name:         "Text",
+			args:         []string{"fix", "typo"},
+			patchStatus:  http.StatusNoContent,
+			expectedTask: "fix typo",
+			expOut:       "Item number 1 changed to: fix typo\n",
+		},
+		{
+			name: "Editor",
+			editor: func(text string) (string, error) {
+				return strings.Replace(text, "task 2", "task two", 1), nil
+			},
+			patchStatus:  http.StatusNoContent,
+			expectedTask: "task two",
+			expOut:       "Item number 1 changed to: task two\n",
+		},
+		{
+			name: "EditorUnchanged",
+			editor: func(text string) (string, error) {
+				return text, nil
+			},
+			expOut: "Item number 1 unchanged\n",
+		},
+		{
+			name: "EditorNotes",
+			editor: func(text string) (string, error) {
+				return strings.Replace(text, "task 2\n", "task 2\n\n# Steps\n\n- one\n- two\n", 1), nil
+			},
+			patchStatus:   http.StatusNoContent,
+			expectedNotes: "# Steps\n\n- one\n- two",
+			expOut:        "Item number 1 notes updated\n",
+		},
+		{
+			name: "EditorEmpty",
+			editor: func(text string) (string, error) {
+				return "\n" + editorHelp, nil
+			},
+			expErr: ErrEmptyTask,
+		},
+		{
+			name:        "Due",
+			due:         time.Date(2024, time.March, 8, 17, 0, 0, 0, time.UTC),
+			patchStatus: http.StatusNoContent,
+			expectedDue: time.Date(2024, time.March, 8, 17, 0, 0, 0, time.UTC),
+			expOut: "Due on " +
+				time.Date(2024, time.March, 8, 17, 0, 0, 0, time.UTC).Local().Format(dueFormat) +
+				"\n",
+		},
+		{
+			name:        "NotFound",
+			args:        []string{"new text"},
+			patchStatus: http.StatusNotFound,
+			expErr:      client.ErrNotFound,
+		},
+		{
+			name:        "InvalidResponse",
+			args:        []string{"new text"},
+			patchStatus: http.StatusInternalServerError,
+			expErr:      client.ErrInvalidResponse,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var (
+				patched      string
+				patchedNotes string
+				patchedDue   time.Time
+			)
+
+			url, cleanup := mockServer(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == http.MethodGet {
+					w.WriteHeader(testResp["resultsOne"].Status)
+					w.Write([]byte(testResp["resultsOne"].Body))
+
+					return
+				}
+
+				if r.Method != http.MethodPatch || r.URL.Path != "/todo/1" {
+					t.Fatalf("Unexpected request: %s %s", r.Method, r.URL.Path)
+				}
+
+				var u client.ItemUpdate
+
+				if err := json.NewDecoder(r.Body).Decode(&u); err != nil {
+					t.Fatalf("Expected a JSON request body, got error: %v", err)
+				}
+
+				if u.Task != nil {
+					patched = *u.Task
+				}
+
+				if u.Notes != nil {
+					patchedNotes = *u.Notes
+				}
+
+				if u.Due != nil {
+					patchedDue = *u.Due
+				}
+
+				w.WriteHeader(tc.patchStatus)
+			})
+
+			defer cleanup()
+
+			var body bytes.Buffer
+
+			err := editAction(context.Background(), &body, url, "1", tc.args, tc.due, tc.editor)
+
+			if tc.expErr != nil {
+				if !errors.Is(err, tc.expErr) {
+					t.Errorf("Expected error: %q, but got: %q instead", tc.expErr, err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Expected no error, but got: %q instead", err)
+			}
+
+			if tc.expectedTask != patched {
+				t.Errorf("Expected task: %q, but got: %q instead", tc.expectedTask, patched)
+			}
+
+			if tc.expectedNotes != patchedNotes {
+				t.Errorf("Expected notes: %q, but got: %q instead", tc.expectedNotes, patchedNotes)
+			}
+
+			if !tc.expectedDue.Equal(patchedDue) {
+				t.Errorf("Expected due date: %s, but got: %s instead", tc.expectedDue, patchedDue)
+			}
+
+			if tc.expOut != body.String() {
+				t.Errorf("Expected output: %q, but got: %q instead", tc.expOut, body.String())
+			}
+		})
+	}
+}
+
+func TestReopenAction(t *testing.T) {
+	testCases := []struct {
+		name        string
+		args        []string
+		status      int
+		expOut      string
+		expectedErr error
+	}{
+		{
+			name:   "Reopened",
+			args:   []string{"1-2"},
+			status: http.StatusNoContent,
+			expOut: "Item number 1 marked as pending\n" +
+				"Item number 2 marked as pending\n",
+		},
+		{
+			name:        "Unsupported",
+			args:        []string{"1"},
+			status:      http.StatusMethodNotAllowed,
+			expectedErr: client.ErrUnsupported,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			url, cleanup := mockServer(func(w http.ResponseWriter, r *http.Request) {
+				if _, ok := r.URL.Query()["reopen"]; !ok || r.Method != http.MethodPatch {
+					t.Fatalf("Unexpected request: %s %s", r.Method, r.URL)
+				}
+
+				w.WriteHeader(tc.status)
+			})
+
+			defer cleanup()
+
+			var body bytes.Buffer
+
+			err := reopenAction(context.Background(), &body, url, tc.args)
+
+			if tc.expectedErr != nil {
+				if !errors.Is(err, tc.expectedErr) {
+					t.Errorf("Expected error: %q, but got: %q instead", tc.expectedErr, err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Expected no error, but got: %q instead", err)
+			}
+
+			if tc.expOut != body.String() {
+				t.Errorf("Expected output: %q, but got: %q instead", tc.expOut, body.String())
+			}
+		})
+	}
+}
+
+func TestDueDates(t *testing.T) {
+	past := time.Now().Add(-48 * time.Hour).Truncate(time.Second)
+	future := time.Now().Add(48 * time.Hour).Truncate(time.Second)
+
+	var added client.NewItem
+
+	url, cleanup := mockServer(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			if err := json.NewDecoder(r.Body).Decode(&added); err != nil {
+				t.Fatal(err)
+			}
+
+			w.WriteHeader(testResp["created"].Status)
+
+			return
+		}
+
+		items := []client.Item{
+			{Task: "file taxes", Due: past},
+			{Task: "book flights", Due: future},
+			{Task: "water plants"},
+			{Task: "send invoice", Done: true, Due: past},
+		}
+
+		json.NewEncoder(w).Encode(client.Response{Results: items, TotalResults: len(items)})
+	})
+
+	defer cleanup()
+
+	var body bytes.Buffer
+
+	if err := addAction(context.Background(), &body, url, []string{"book", "flights"}, addOptions{due: future}); err != nil {
+		t.Fatalf("Expected no error, but got: %q instead", err)
+	}
+
+	if added.Due == nil || !added.Due.Equal(future) {
+		t.Errorf("Expected due date: %s to be sent, but got: %v instead", future, added.Due)
+	}
+
+	expectedOutput := "Added item: book flights : to the list\n" +
+		"Due on " + future.Local().Format(dueFormat) + "\n"
+
+	if expectedOutput != body.String() {
+		t.Errorf("Expected output: %q, but got: %q instead", expectedOutput, body.String())
+	}
+
+	pastDue := past.Local().Format(dueFormat)
+	futureDue := future.Local().Format(dueFormat)
+
+	testCases := []struct {
+		name           string
+		args           []string
+		expectedOutput string
+		expectedErr    error
+	}{
+		{
+			name: "DueColumn",
+			expectedOutput: "𝘅  1  file taxes    " + pastDue + " (overdue)\n" +
+				"𝘅  2  book flights  " + futureDue + "\n" +
+				"𝘅  3  water plants\n" +
+				"✅  4  send invoice  " + pastDue + "\n",
+		},
+		{
+			name:           "Overdue",
+			args:           []string{"--overdue"},
+			expectedOutput: "𝘅  1  file taxes  " + pastDue + " (overdue)\n",
+		},
+		{
+			name: "DueBefore",
+			args: []string{"--due-before", "in 3 days"},
+			expectedOutput: "𝘅  1  file taxes    " + pastDue + " (overdue)\n" +
+				"𝘅  2  book flights  " + futureDue + "\n" +
+				"✅  4  send invoice  " + pastDue + "\n",
+		},
+		{
+			name: "SortByDue",
+			args: []string{"--sort", "due", "--pending"},
+			expectedOutput: "𝘅  1  file taxes    " + pastDue + " (overdue)\n" +
+				"𝘅  2  book flights  " + futureDue + "\n" +
+				"𝘅  3  water plants\n",
+		},
+		{
+			name:        "OverdueAndDone",
+			args:        []string{"--overdue", "--done"},
+			expectedErr: ErrInvalidFilter,
+		},
+		{
+			name:        "BadDueBefore",
+			args:        []string{"--due-before", "someday"},
+			expectedErr: ErrInvalidFilter,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cmd := &cobra.Command{}
+			cmd.Flags().AddFlagSet(listCmd.Flags())
+
+			defer listCmd.Flags().VisitAll(resetFlag)
+
+			if err := cmd.Flags().Parse(tc.args); err != nil {
+				t.Fatal(err)
+			}
+
+			var body bytes.Buffer
+
+			opts, err := listOptionsFromFlags(cmd)
+			if err == nil {
+				err = listAction(context.Background(), &body, url, textFormatter{}, opts)
+			}
+
+			if tc.expectedErr != nil {
+				if !errors.Is(err, tc.expectedErr) {
+					t.Errorf("Expected error: %q, but got: %q instead", tc.expectedErr, err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Expected no error, but got: %q instead", err)
+			}
+
+			if tc.expectedOutput != body.String() {
+				t.Errorf("Expected output: %q, but got: %q instead", tc.expectedOutput, body.String())
+			}
+		})
+	}
+}
+
+// resetFlag restores the default value of f for the next test case.
+func resetFlag(f *pflag.Flag) {
+	if sv, ok := f.Value.(pflag.SliceValue); ok {
+		sv.Replace(nil)
+	} else {
+		f.Value.Set(f.DefValue)
+	}
+
+	f.Changed = false
+}
+
+func TestTagsAndPriorities(t *testing.T) {
+	var added client.NewItem
+
+	items := []client.Item{
+		{Task: "fix login", Priority: "high", Tags: []string{"backend", "urgent"}},
+		{Task: "update docs", Priority: "low", Tags: []string{"docs"}},
+		{Task: "review PR", Tags: []string{"Backend"}},
+		{Task: "plan sprint", Priority: "medium"},
+	}
+
+	url, cleanup := mockServer(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			if err := json.NewDecoder(r.Body).Decode(&added); err != nil {
+				t.Fatal(err)
+			}
+
+			w.WriteHeader(testResp["created"].Status)
+
+			return
+		}
+
+		json.NewEncoder(w).Encode(client.Response{Results: items, TotalResults: len(items)})
+	})
+
+	defer cleanup()
+
+	var body bytes.Buffer
+
+	opts := addOptions{priority: "medium", tags: []string{"backend"}}
+
+	err := addAction(
+		context.Background(), &body, url, []string{"fix login +Backend !high +urgent"}, opts,
+	)
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %q instead", err)
+	}
+
+	expectedItem := client.NewItem{
+		Task:     "fix login",
+		Priority: "medium",
+		Tags:     []string{"backend", "urgent"},
+	}
+
+	if !reflect.DeepEqual(expectedItem, added) {
+		t.Errorf("Expected request: %+v, but got: %+v instead", expectedItem, added)
+	}
+
+	expectedOutput := "Added item: fix login : to the list\n" +
+		"Priority: medium\n" +
+		"Tags: backend, urgent\n"
+
+	if expectedOutput != body.String() {
+		t.Errorf("Expected output: %q, but got: %q instead", expectedOutput, body.String())
+	}
+
+	err = addAction(context.Background(), &body, url, []string{"+backend", "!high"}, addOptions{})
+	if !errors.Is(err, ErrEmptyTask) {
+		t.Errorf("Expected error: %q, but got: %q instead", ErrEmptyTask, err)
+	}
+
+	testCases := []struct {
+		name           string
+		args           []string
+		expectedOutput string
+		expectedErr    error
+	}{
+		{
+			name: "Labels",
+			expectedOutput: "𝘅  1  fix login !high +backend +urgent\n" +
+				"𝘅  2  update docs !low +docs          \n" +
+				"𝘅  3  review PR +Backend              \n" +
+				"𝘅  4  plan sprint !medium             \n",
+		},
+		{
+			name: "Tag",
+			args: []string{"--tag", "backend"},
+			expectedOutput: "𝘅  1  fix login !high +backend +urgent\n" +
+				"𝘅  3  review PR +Backend              \n",
+		},
+		{
+			name:           "Tags",
+			args:           []string{"--tag", "backend", "--tag", "urgent"},
+			expectedOutput: "𝘅  1  fix login !high +backend +urgent\n",
+		},
+		{
+			name:           "Priority",
+			args:           []string{"--priority", "LOW"},
+			expectedOutput: "𝘅  2  update docs !low +docs\n",
+		},
+		{
+			name: "SortByPriority",
+			args: []string{"--sort", "priority"},
+			expectedOutput: "𝘅  1  fix login !high +backend +urgent\n" +
+				"𝘅  4  plan sprint !medium             \n" +
+				"𝘅  2  update docs !low +docs          \n" +
+				"𝘅  3  review PR +Backend              \n",
+		},
+		{
+			name:        "BadPriority",
+			args:        []string{"--priority", "urgent"},
+			expectedErr: ErrInvalidFilter,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cmd := &cobra.Command{}
+			cmd.Flags().AddFlagSet(listCmd.Flags())
+
+			defer listCmd.Flags().VisitAll(resetFlag)
+
+			if err := cmd.Flags().Parse(tc.args); err != nil {
+				t.Fatal(err)
+			}
+
+			var body bytes.Buffer
+
+			opts, err := listOptionsFromFlags(cmd)
+			if err == nil {
+				err = listAction(context.Background(), &body, url, textFormatter{}, opts)
+			}
+
+			if tc.expectedErr != nil {
+				if !errors.Is(err, tc.expectedErr) {
+					t.Errorf("Expected error: %q, but got: %q instead", tc.expectedErr, err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Expected no error, but got: %q instead", err)
+			}
+
+			if tc.expectedOutput != body.String() {
+				t.Errorf("Expected output: %q, but got: %q instead", tc.expectedOutput, body.String())
+			}
+		})
+	}
+
+	// Tags survive every output format.
+	for _, format := range formatterNames() {
+		t.Run("Format"+strings.ToUpper(format), func(t *testing.T) {
+			f, err := getFormatter(format)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var body bytes.Buffer
+
+			if err := listAction(context.Background(), &body, url, f, listOptions{}); err != nil {
+				t.Fatalf("Expected no error, but got: %q instead", err)
+			}
+
+			for _, tag := range []string{"backend", "urgent", "docs", "Backend"} {
+				if !strings.Contains(body.String(), tag) {
+					t.Errorf("Expected tag %q in the %s output:\n%s", tag, format, body.String())
+				}
+			}
+		})
+	}
+
+	body.Reset()
+
+	if err := listAction(context.Background(), &body, url, jsonFormatter{}, listOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	var records []record
+
+	if err := json.Unmarshal(body.Bytes(), &records); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(items[0].Tags, records[0].Tags) || records[0].Priority != "high" {
+		t.Errorf("Expected the tags and priority to round-trip, but got: %+v", records[0])
+	}
+}
+
+func TestListsActions(t *testing.T) {
+	var requests []string
+
+	url, cleanup := mockServer(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/lists":
+			w.Write([]byte(`{"results": [
+				{"name": "home", "total": 3, "pending": 1},
+				{"name": "work", "total": 12, "pending": 5}
+			]}`))
+		case r.Method == http.MethodGet:
+			w.WriteHeader(testResp["resultsMany"].Status)
+			w.Write([]byte(testResp["resultsMany"].Body))
+		case r.Method == http.MethodPost:
+			w.WriteHeader(testResp["created"].Status)
+		default:
+			w.WriteHeader(testResp["noContent"].Status)
+		}
+	})
+
+	defer cleanup()
+
+	ctx := context.Background()
+
+	var body bytes.Buffer
+
+	if err := listsAction(ctx, &body, url, "work"); err != nil {
+		t.Fatalf("Expected no error, but got: %q instead", err)
+	}
+
+	expectedOutput := "CURRENT  NAME  ITEMS  PENDING\n" +
+		"         home  3      1\n" +
+		"*        work  12     5\n"
+
+	if expectedOutput != body.String() {
+		t.Errorf("Expected output: %q, but got: %q instead", expectedOutput, body.String())
+	}
+
+	body.Reset()
+
+	if err := listsCreateAction(ctx, &body, url, "garden"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := listsRenameAction(ctx, &body, url, "garden", "yard"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := listsDeleteAction(ctx, &body, url, "yard"); err != nil {
+		t.Fatal(err)
+	}
+
+	expectedOutput = "Created list \"garden\"\n" +
+		"Renamed list \"garden\" to \"yard\"\n" +
+		"Deleted list \"yard\"\n"
+
+	if expectedOutput != body.String() {
+		t.Errorf("Expected output: %q, but got: %q instead", expectedOutput, body.String())
+	}
+
+	// Item commands work on the list set with --list.
+	viper.Set("list", "work")
+	defer viper.Set("list", "")
+
+	requests = nil
+
+	if err := listAction(ctx, io.Discard, url, textFormatter{}, listOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := completeAction(ctx, io.Discard, url, []string{"2"}, false); err != nil {
+		t.Fatal(err)
+	}
+
+	expectedRequests := []string{
+		"GET /lists/work/todo", "GET /lists/work/todo/2", "PATCH /lists/work/todo/2",
+	}
+
+	if !reflect.DeepEqual(expectedRequests, requests) {
+		t.Errorf("Expected requests: %q, but got: %q instead", expectedRequests, requests)
+	}
+}
+
+func TestSubtasks(t *testing.T) {
+	var (
+		added    client.NewItem
+		requests []string
+	)
+
+	items := []client.Item{
+		{ID: 1, Task: "release"},
+		{ID: 2, Task: "write notes", Done: true, Parent: 1},
+		{ID: 3, Task: "tag build", Parent: 1},
+		{ID: 4, Task: "push tag", Parent: 3},
+		{ID: 5, Task: "plan sprint"},
+	}
+
+	url, cleanup := mockServer(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.RequestURI())
+
+		switch {
+		case r.Method == http.MethodPost:
+			if err := json.NewDecoder(r.Body).Decode(&added); err != nil {
+				t.Fatal(err)
+			}
+
+			w.WriteHeader(testResp["created"].Status)
+		case r.Method == http.MethodPatch:
+			w.WriteHeader(testResp["noContent"].Status)
+		default:
+			serveItems(w, r, items)
+		}
+	})
+
+	defer cleanup()
+
+	ctx := context.Background()
+
+	var body bytes.Buffer
+
+	if err := addAction(ctx, &body, url, []string{"bump version"}, addOptions{parent: "#3"}); err != nil {
+		t.Fatalf("Expected no error, but got: %q instead", err)
+	}
+
+	if added.Parent != 3 {
+		t.Errorf("Expected parent: 3, but got: %d instead", added.Parent)
+	}
+
+	expectedOutput := "Added item: bump version : to the list\n" +
+		"Subtask of item number 3\n"
+
+	if expectedOutput != body.String() {
+		t.Errorf("Expected output: %q, but got: %q instead", expectedOutput, body.String())
+	}
+
+	t.Run("View", func(t *testing.T) {
+		var body bytes.Buffer
+
+		if err := viewAction(ctx, &body, url, "1", textFormatter{}); err != nil {
+			t.Fatalf("Expected no error, but got: %q instead", err)
+		}
+
+		_, subtasks, _ := strings.Cut(body.String(), "Completed:    No\n")
+		expectedOutput := "\nSubtasks (1/2 done):\n" +
+			"  [x] 2 write notes\n" +
+			"  [ ] 3 tag build\n"
+
+		if expectedOutput != subtasks {
+			t.Errorf("Expected output: %q, but got: %q instead", expectedOutput, subtasks)
+		}
+	})
+
+	t.Run("Tree", func(t *testing.T) {
+		var body bytes.Buffer
+
+		if err := listAction(ctx, &body, url, treeFormatter{}, listOptions{}); err != nil {
+			t.Fatalf("Expected no error, but got: %q instead", err)
+		}
+
+		expectedOutput := "𝘅 1 release (1/2 done)\n" +
+			"├── ✅ 2 write notes\n" +
+			"└── 𝘅 3 tag build (0/1 done)\n" +
+			"    └── 𝘅 4 push tag\n" +
+			"𝘅 5 plan sprint\n"
+
+		if expectedOutput != body.String() {
+			t.Errorf("Expected output: %q, but got: %q instead", expectedOutput, body.String())
+		}
+	})
+
+	testCases := []struct {
+		name             string
+		args             []string
+		recursive        bool
+		expectedRequests []string
+		expectedOutput   string
+		expectedWarning  string
+	}{
+		{
+			name: "Warning",
+			args: []string{"1"},
+			// The item is fetched, and its subtasks asked for by parent.
+			expectedRequests: []string{
+				"GET /todo/1", "GET /todo?parent=1", "GET /todo?parent=2",
+				"GET /todo?parent=3", "GET /todo?parent=4", "PATCH /todo/1?complete",
+			},
+			expectedOutput:  "Item number 1 marked as complete\n",
+			expectedWarning: "Warning: item number 1 has 2 pending subtasks, use --recursive to complete them too\n",
+		},
+		{
+			name: "WithSubtasks",
+			args: []string{"1", "3", "4"},
+			expectedRequests: []string{
+				"GET /todo/1", "GET /todo?parent=1", "GET /todo?parent=2",
+				"GET /todo?parent=3", "GET /todo?parent=4", "GET /todo/3", "GET /todo/4",
+				"PATCH /todo/1?complete", "PATCH /todo/3?complete", "PATCH /todo/4?complete",
+			},
+			expectedOutput: "Item number 1 marked as complete\n" +
+				"Item number 3 marked as complete\n" +
+				"Item number 4 marked as complete\n",
+		},
+		{
+			name:      "Recursive",
+			args:      []string{"1"},
+			recursive: true,
+			expectedRequests: []string{
+				"GET /todo", "PATCH /todo/4?complete", "PATCH /todo/3?complete", "PATCH /todo/1?complete",
+			},
+			expectedOutput: "Item number 4 marked as complete\n" +
+				"Item number 3 marked as complete\n" +
+				"Item number 1 marked as complete\n",
+		},
+	}
+
+	// Complete one item at a time, so that the requests arrive in order.
+	viper.Set("parallel", 1)
+	defer viper.Set("parallel", 4)
+
+	var warnings bytes.Buffer
+
+	stderr = &warnings
+	defer func() { stderr = os.Stderr }()
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			requests = nil
+			warnings.Reset()
+
+			var body bytes.Buffer
+
+			if err := completeAction(ctx, &body, url, tc.args, tc.recursive); err != nil {
+				t.Fatalf("Expected no error, but got: %q instead", err)
+			}
+
+			if !reflect.DeepEqual(tc.expectedRequests, requests) {
+				t.Errorf("Expected requests: %q, but got: %q instead", tc.expectedRequests, requests)
+			}
+
+			if tc.expectedOutput != body.String() {
+				t.Errorf("Expected output: %q, but got: %q instead", tc.expectedOutput, body.String())
+			}
+
+			if tc.expectedWarning != warnings.String() {
+				t.Errorf("Expected warning: %q, but got: %q instead", tc.expectedWarning, warnings.String())
+			}
+		})
+	}
+}
+
+func TestRecurringItems(t *testing.T) {
+	var added []client.NewItem
+
+	now := time.Now()
+	tomorrow := time.Date(now.Year(), now.Month(), now.Day()+1, 9, 0, 0, 0, time.Local)
+	overdueDue := tomorrow.AddDate(0, 0, -16)
+
+	items := []client.Item{
+		{ID: 1, Task: "rotate on-call", Due: tomorrow, Recurrence: "FREQ=DAILY", Tags: []string{"ops"}},
+		{ID: 2, Task: "weekly report", Due: overdueDue, Recurrence: "FREQ=WEEKLY"},
+		{ID: 3, Task: "ship it"},
+		{ID: 4, Task: "water plants", Done: true, Recurrence: "FREQ=WEEKLY"},
+	}
+
+	url, cleanup := mockServer(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost:
+			var item client.NewItem
+
+			if err := json.NewDecoder(r.Body).Decode(&item); err != nil {
+				t.Fatal(err)
+			}
+
+			added = append(added, item)
+
+			w.WriteHeader(testResp["created"].Status)
+		case r.Method == http.MethodPatch:
+			w.WriteHeader(testResp["noContent"].Status)
+		default:
+			serveItems(w, r, items)
+		}
+	})
+
+	defer cleanup()
+
+	ctx := context.Background()
+
+	t.Run("Add", func(t *testing.T) {
+		added = nil
+
+		var body bytes.Buffer
+
+		err := addAction(ctx, &body, url, []string{"standup"}, addOptions{every: "weekly on mon,thu"})
+		if err != nil {
+			t.Fatalf("Expected no error, but got: %q instead", err)
+		}
+
+		if len(added) != 1 || added[0].Recurrence != "FREQ=WEEKLY;BYDAY=MO,TH" {
+			t.Fatalf("Expected a recurring item, but got: %+v instead", added)
+		}
+
+		due := added[0].Due
+		if due == nil || (due.Weekday() != time.Monday && due.Weekday() != time.Thursday) {
+			t.Errorf("Expected a due date on a Monday or a Thursday, but got: %v instead", due)
+		}
+
+		expectedOutput := "Added item: standup : to the list\n" +
+			"Repeats: FREQ=WEEKLY;BYDAY=MO,TH\n" +
+			"Due on " + due.Local().Format(dueFormat) + "\n"
+
+		if expectedOutput != body.String() {
+			t.Errorf("Expected output: %q, but got: %q instead", expectedOutput, body.String())
+		}
+	})
+
+	t.Run("AddInvalid", func(t *testing.T) {
+		err := addAction(ctx, io.Discard, url, []string{"standup"}, addOptions{every: "sometimes"})
+
+		if !errors.Is(err, ErrInvalidRecurrence) {
+			t.Errorf("Expected error: %q, but got: %q instead", ErrInvalidRecurrence, err)
+		}
+	})
+
+	t.Run("Complete", func(t *testing.T) {
+		added = nil
+
+		var body bytes.Buffer
+
+		if err := completeAction(ctx, &body, url, []string{"1-4"}, false); err != nil {
+			t.Fatalf("Expected no error, but got: %q instead", err)
+		}
+
+		// Missed occurrences of an overdue item are skipped, and items that
+		// were already done get no new occurrence.
+		nextDaily, nextWeekly := tomorrow.AddDate(0, 0, 1), overdueDue.AddDate(0, 0, 21)
+
+		expectedAdded := []client.NewItem{
+			{Task: "rotate on-call", Due: &nextDaily, Tags: []string{"ops"}, Recurrence: "FREQ=DAILY"},
+			{Task: "weekly report", Due: &nextWeekly, Recurrence: "FREQ=WEEKLY"},
+		}
+
+		if len(added) != len(expectedAdded) {
+			t.Fatalf("Expected new items: %+v, but got: %+v instead", expectedAdded, added)
+		}
+
+		for i := range added {
+			if added[i].Due == nil || !added[i].Due.Equal(*expectedAdded[i].Due) {
+				t.Errorf("Expected due date: %v, but got: %v instead", expectedAdded[i].Due, added[i].Due)
+			}
+
+			added[i].Due = expectedAdded[i].Due
+		}
+
+		if !reflect.DeepEqual(expectedAdded, added) {
+			t.Errorf("Expected new items: %+v, but got: %+v instead", expectedAdded, added)
+		}
+
+		output := body.String()
+
+		for _, line := range []string{
+			"Next occurrence of item number 1 added, due on " + nextDaily.Format(dueFormat) + "\n",
+			"Next occurrence of item number 2 added, due on " + nextWeekly.Format(dueFormat) + "\n",
+		} {
+			if !strings.Contains(output, line) {
+				t.Errorf("Expected output to contain: %q, but got: %q instead", line, output)
+			}
+		}
+	})
+
+	t.Run("View", func(t *testing.T) {
+		var body bytes.Buffer
+
+		if err := viewAction(ctx, &body, url, "1", textFormatter{}); err != nil {
+			t.Fatalf("Expected no error, but got: %q instead", err)
+		}
+
+		dates := []string{
+			tomorrow.AddDate(0, 0, 1).Format(dueFormat),
+			tomorrow.AddDate(0, 0, 2).Format(dueFormat),
+			tomorrow.AddDate(0, 0, 3).Format(dueFormat),
+		}
+
+		expected := "Repeats:      FREQ=DAILY\n" +
+			"Next dates:   " + strings.Join(dates, ", ") + "\n"
+
+		if !strings.Contains(body.String(), expected) {
+			t.Errorf("Expected output to contain: %q, but got: %q instead", expected, body.String())
+		}
+	})
+}
@@ -22,36 +22,247 @@ THE SOFTWARE.
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"strings"
+	"time"
 
+	"github.com/mycok/todo_list_client/client"
+	"github.com/mycok/todo_list_client/dateparse"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
 
+var ErrInvalidDue = errors.New("invalid due date")
+
 // addCmd represents the add command
 var addCmd = &cobra.Command{
-	Use:          "add <itemName>",
-	Short:        "Add a new item",
+	Use:   "add <itemName>",
+	Short: "Add a new item",
+	Long: `Add a new item.
+
+The --due date can be an ISO date such as 2024-03-01 17:00, or a phrase
+such as today, tomorrow 9am, in 3 days or next friday 17:00, in the local
+time zone. A date without a time of day is due at the end of that day.
+
+Tags and the priority can also be given in the task text, as +tag and
+!high, !medium or !low, e.g. "add fix login +backend !high". The --priority
+flag takes precedence over the one in the text.
+
+Notes are given with --note or --note-file, or piped in, e.g.
+"add deploy < notes.md".
+
+With --parent, the item is added as a subtask of the given item, referred
+to by its ID or its position with #N.
+
+When the API is unreachable, the item is queued in the outbox and added by
+the sync command, or by the next command that reaches the API.
+
+Recurring items are added with --every and a schedule such as daily,
+"weekly on mon", "every 2 weeks", "monthly on the 15th" or an RFC 5545
+RRULE such as "FREQ=WEEKLY;BYDAY=MO,TH". Without --due, the item is due on
+the first day of the schedule, starting today. Completing the item adds its
+next occurrence.`,
 	SilenceUsage: true,
 	Args:         cobra.MinimumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		rootURL := viper.GetString("api-root")
 
-		return addAction(os.Stdout, rootURL, args)
+		opts, err := addOptionsFromFlags(cmd)
+		if err != nil {
+			return err
+		}
+
+		return addAction(cmd.Context(), os.Stdout, rootURL, args, opts)
 	},
 }
 
-func addAction(w io.Writer, url string, args []string) error {
-	name := strings.Join(args, " ")
+// addOptions holds the fields of a new item given with flags rather than in
+// the task text.
+type addOptions struct {
+	due      time.Time
+	priority string
+	tags     []string
+	notes    string
+	parent   string
+	every    string
+}
+
+func addOptionsFromFlags(cmd *cobra.Command) (addOptions, error) {
+	var opts addOptions
+
+	due, err := dueFromFlags(cmd)
+	if err != nil {
+		return opts, err
+	}
+
+	priority, _ := cmd.Flags().GetString("priority")
+
+	opts.priority, err = parsePriority(priority)
+	if err != nil {
+		return opts, err
+	}
+
+	opts.notes, err = notesFromFlags(cmd, os.Stdin)
+	if err != nil {
+		return opts, err
+	}
+
+	opts.due = due
+	opts.tags, _ = cmd.Flags().GetStringArray("tag")
+	opts.parent, _ = cmd.Flags().GetString("parent")
+	opts.every, _ = cmd.Flags().GetString("every")
+
+	return opts, nil
+}
+
+func addAction(
+	ctx context.Context, w io.Writer, url string, args []string, opts addOptions,
+) error {
+	name, tags, priority := parseTaskText(strings.Join(args, " "))
+
+	if name == "" {
+		return fmt.Errorf("%w: the task has no text besides its tags", ErrEmptyTask)
+	}
+
+	if opts.priority != "" {
+		priority = opts.priority
+	}
+
+	item := client.NewItem{
+		Task:     name,
+		Priority: priority,
+		Tags:     mergeTags(opts.tags, tags),
+		Notes:    opts.notes,
+	}
+
+	due := opts.due
+
+	if opts.every != "" {
+		rule, err := parseRecurrence(opts.every)
+		if err != nil {
+			return err
+		}
+
+		if due.IsZero() {
+			today, _ := dateparse.ParseDeadline("today", time.Now())
+
+			if due = rule.First(today); due.IsZero() {
+				return fmt.Errorf("%w: the schedule has no dates left", ErrInvalidRecurrence)
+			}
+		}
+
+		item.Recurrence = rule.String()
+	}
+
+	if !due.IsZero() {
+		item.Due = &due
+	}
+
+	c, err := newClient(url)
+	if err != nil {
+		return err
+	}
+
+	resumeOutbox(ctx, c)
+
+	if opts.parent != "" {
+		if item.Parent, err = resolveParent(ctx, c, opts.parent); err != nil {
+			return err
+		}
+	}
+
+	op, err := newOperation(opAdd, 0, &item)
+	if err != nil {
+		return err
+	}
+
+	queued, err := sendOrQueue(ctx, c, op)
+	if err != nil {
+		return err
+	}
+
+	if queued {
+		_, err := fmt.Fprintf(
+			w, "API unreachable, item queued to be added: %s\n"+
+				"Run sync to send the queued operations\n",
+			name,
+		)
+
+		return err
+	}
 
-	if err := addItem(url, name); err != nil {
+	if err := printAddedItem(w, name); err != nil {
 		return err
 	}
 
-	return printAddedItem(w, name)
+	if item.Priority != "" {
+		if _, err := fmt.Fprintf(w, "Priority: %s\n", item.Priority); err != nil {
+			return err
+		}
+	}
+
+	if len(item.Tags) > 0 {
+		if _, err := fmt.Fprintf(w, "Tags: %s\n", strings.Join(item.Tags, ", ")); err != nil {
+			return err
+		}
+	}
+
+	if item.Parent != 0 {
+		if _, err := fmt.Fprintf(w, "Subtask of item number %d\n", item.Parent); err != nil {
+			return err
+		}
+	}
+
+	if item.Recurrence != "" {
+		if _, err := fmt.Fprintf(w, "Repeats: %s\n", item.Recurrence); err != nil {
+			return err
+		}
+	}
+
+	if due.IsZero() {
+		return nil
+	}
+
+	return printDue(w, due)
+}
+
+// resolveParent returns the ID of the item given to --parent.
+func resolveParent(ctx context.Context, c *client.Client, parent string) (int, error) {
+	refs, err := parseIDs([]string{parent})
+	if err != nil {
+		return 0, err
+	}
+
+	if len(refs) != 1 {
+		return 0, fmt.Errorf("%w: --parent takes a single item", ErrInvalidID)
+	}
+
+	ids, err := resolveIDs(ctx, c, refs)
+	if err != nil {
+		return 0, err
+	}
+
+	return ids[0], nil
+}
+
+// dueFromFlags parses the --due flag of cmd, returning the zero time when
+// it is not set.
+func dueFromFlags(cmd *cobra.Command) (time.Time, error) {
+	value, err := cmd.Flags().GetString("due")
+	if err != nil || value == "" {
+		return time.Time{}, err
+	}
+
+	due, err := dateparse.ParseDeadline(value, time.Now())
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: %s", ErrInvalidDue, err)
+	}
+
+	return due, nil
 }
 
 func printAddedItem(w io.Writer, name string) error {
@@ -60,6 +271,20 @@ func printAddedItem(w io.Writer, name string) error {
 	return err
 }
 
+func printDue(w io.Writer, due time.Time) error {
+	_, err := fmt.Fprintf(w, "Due on %s\n", due.Local().Format(dueFormat))
+
+	return err
+}
+
 func init() {
 	rootCmd.AddCommand(addCmd)
+
+	addCmd.Flags().String("due", "", "Due date, e.g. 2024-03-01, tomorrow 17:00 or next friday")
+	addCmd.Flags().String("priority", "", "Priority: high, medium or low")
+	addCmd.Flags().StringArray("tag", nil, "Tag to add to the item, can be repeated")
+	addCmd.Flags().String("note", "", "Notes describing the item")
+	addCmd.Flags().String("note-file", "", "File holding the notes describing the item")
+	addCmd.Flags().String("parent", "", "Item to add the new item as a subtask of")
+	addCmd.Flags().String("every", "", "Schedule of a recurring item, e.g. daily, \"weekly on mon\" or an RRULE")
 }
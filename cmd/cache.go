@@ -0,0 +1,133 @@
+/*
+Copyright © 2022 mycok <github.com/mycok>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/mycok/todo_list_client/client"
+	"github.com/spf13/cobra"
+)
+
+// cacheCmd represents the cache command
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Inspect and clear the cache of API responses",
+	Long: `Inspect and clear the cache of API responses.
+
+Responses the API sends with an ETag or Last-Modified header are kept in
+the todo_list_client directory of the user cache directory
+($XDG_CACHE_HOME, ~/.cache by default on Linux). Later requests for the
+same items ask the API whether they changed, and a 304 Not Modified answer
+is served from the cache instead of downloading the items again.
+
+Use the global --no-cache flag to bypass the cache for a single command.`,
+}
+
+var cacheClearCmd = &cobra.Command{
+	Use:          "clear",
+	Short:        "Remove every cached response",
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cache, err := httpCache()
+		if err != nil {
+			return err
+		}
+
+		return cacheClearAction(os.Stdout, cache)
+	},
+}
+
+var cacheStatsCmd = &cobra.Command{
+	Use:          "stats",
+	Short:        "Show the size of the cache and how often it was used",
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cache, err := httpCache()
+		if err != nil {
+			return err
+		}
+
+		return cacheStatsAction(os.Stdout, cache)
+	},
+}
+
+func cacheClearAction(w io.Writer, cache *client.DiskCache) error {
+	n, err := cache.Clear()
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "Removed %d cached responses\n", n)
+
+	return err
+}
+
+func cacheStatsAction(w io.Writer, cache *client.DiskCache) error {
+	stats, err := cache.Stats()
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "Location: %s\n", cache.Dir)
+	fmt.Fprintf(w, "Entries:  %d\n", stats.Entries)
+	fmt.Fprintf(w, "Size:     %s\n", formatSize(stats.Size))
+	fmt.Fprintf(w, "Hits:     %d\n", stats.Hits)
+
+	if stats.Entries == 0 {
+		return nil
+	}
+
+	fmt.Fprintf(w, "Oldest:   %s\n", stats.Oldest.Local().Format(dueFormat))
+	_, err = fmt.Fprintf(w, "Newest:   %s\n", stats.Newest.Local().Format(dueFormat))
+
+	return err
+}
+
+// formatSize returns size in bytes in a human readable form, such as 1.5 KiB.
+func formatSize(size int64) string {
+	const unit = 1024
+
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+
+	div, exp := int64(unit), 0
+
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
+
+func init() {
+	rootCmd.AddCommand(cacheCmd)
+
+	cacheCmd.AddCommand(cacheClearCmd)
+	cacheCmd.AddCommand(cacheStatsCmd)
+}
@@ -0,0 +1,123 @@
+//go:build !integration
+// +build !integration
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/mycok/todo_list_client/client"
+	"github.com/spf13/viper"
+)
+
+func TestCacheActions(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	var conditional []bool
+
+	url, cleanup := mockServer(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+
+		conditional = append(conditional, r.Header.Get("If-None-Match") != "")
+
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+
+			return
+		}
+
+		w.WriteHeader(testResp["resultsMany"].Status)
+		w.Write([]byte(testResp["resultsMany"].Body))
+	})
+
+	defer cleanup()
+
+	list := func(t *testing.T) string {
+		t.Helper()
+
+		var out bytes.Buffer
+
+		if err := listAction(context.Background(), &out, url, textFormatter{}, listOptions{}); err != nil {
+			t.Fatalf("Expected no error, but got: %q instead", err)
+		}
+
+		return out.String()
+	}
+
+	first := list(t)
+
+	if again := list(t); again != first {
+		t.Errorf("Expected output: %q, but got: %q instead", first, again)
+	}
+
+	viper.Set("no-cache", true)
+	list(t)
+	viper.Set("no-cache", false)
+
+	expected := []bool{false, true, false}
+
+	if len(conditional) != len(expected) {
+		t.Fatalf("Expected %d requests, but got: %d instead", len(expected), len(conditional))
+	}
+
+	for i := range expected {
+		if conditional[i] != expected[i] {
+			t.Errorf("Expected conditional requests: %v, but got: %v instead", expected, conditional)
+
+			break
+		}
+	}
+
+	cache, err := httpCache()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+
+	if err := cacheStatsAction(&out, cache); err != nil {
+		t.Fatalf("Expected no error, but got: %q instead", err)
+	}
+
+	for _, line := range []string{"Entries:  1\n", "Hits:     1\n"} {
+		if !strings.Contains(out.String(), line) {
+			t.Errorf("Expected output to contain: %q, but got: %q instead", line, out.String())
+		}
+	}
+
+	out.Reset()
+
+	if err := cacheClearAction(&out, cache); err != nil {
+		t.Fatalf("Expected no error, but got: %q instead", err)
+	}
+
+	if out.String() != "Removed 1 cached responses\n" {
+		t.Errorf("Expected output: %q, but got: %q instead", "Removed 1 cached responses\n", out.String())
+	}
+
+	if stats, _ := cache.Stats(); stats != (client.CacheStats{}) {
+		t.Errorf("Expected an empty cache, but got: %+v instead", stats)
+	}
+}
+
+func TestFormatSize(t *testing.T) {
+	testCases := []struct {
+		size     int64
+		expected string
+	}{
+		{size: 0, expected: "0 B"},
+		{size: 1023, expected: "1023 B"},
+		{size: 1536, expected: "1.5 KiB"},
+		{size: 5 << 20, expected: "5.0 MiB"},
+	}
+
+	for _, tc := range testCases {
+		if got := formatSize(tc.size); got != tc.expected {
+			t.Errorf("Expected size: %q, but got: %q instead", tc.expected, got)
+		}
+	}
+}
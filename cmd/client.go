@@ -1,170 +1,157 @@
 package cmd
 
 import (
-	"bytes"
-	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
 	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
 	"time"
-)
-
-const timeFormat = "Jan/02 @15:00"
 
-var (
-	ErrConnection      = errors.New("connection error")
-	ErrNotFound        = errors.New("not found")
-	ErrInvalidResponse = errors.New("invalid response")
-	ErrInvalid         = errors.New("invalid data")
-	ErrNotNumber       = errors.New("not a number")
+	"github.com/mycok/todo_list_client/client"
+	"github.com/spf13/viper"
 )
 
-type item struct {
-	Task        string
-	Done        bool
-	CreatedAt   time.Time
-	CompletedAt time.Time
-}
+const (
+	timeFormat = "Jan/02 @15:00"
 
-type response struct {
-	Results      []item `json:"results"`
-	Date         int    `json:"date"`
-	TotalResults int    `json:"total_results"`
-}
+	// dueFormat shows the minutes and the weekday, which matter for
+	// deadlines.
+	dueFormat = "Mon Jan/02 @15:04"
 
-func getAll(url string) ([]item, error) {
-	u := fmt.Sprintf("%s/todo", url)
+	// retryBaseDelay is the wait before the first retry of a request.
+	retryBaseDelay = 200 * time.Millisecond
 
-	return getItems(u)
-}
+	// retryJitter is the fraction of every retry wait that is randomized.
+	retryJitter = 0.5
+)
 
-func getItem(url string, id int) (item, error) {
-	u := fmt.Sprintf("%s/todo/%d", url, id)
+var ErrNotNumber = errors.New("not a number")
 
-	items, err := getItems(u)
+// newClient returns an API client for the todo_list_api server at url.
+func newClient(url string) (*client.Client, error) {
+	auth, err := newAuth(url)
 	if err != nil {
-		return item{}, err
+		return nil, err
 	}
 
-	return items[0], nil
-}
-
-func getItems(url string) ([]item, error) {
-	resp, err := newClient().Get(url)
+	transport, err := newTransport()
 	if err != nil {
-		return nil, fmt.Errorf("%w: %s", ErrConnection, err)
+		return nil, err
 	}
 
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		errMsg, err := io.ReadAll(resp.Body)
-		if err != nil {
-			return nil, fmt.Errorf("failed to read body: %w", err)
-		}
+	c := client.New(url)
+	c.Auth = auth
+	c.ListName = viper.GetString("list")
+	c.HTTPClient.Timeout = viper.GetDuration("timeout")
+	c.HTTPClient.Transport = &client.RetryTransport{
+		Base: transport,
+		Policy: client.RetryPolicy{
+			MaxAttempts: viper.GetInt("retries") + 1,
+			BaseDelay:   retryBaseDelay,
+			MaxDelay:    viper.GetDuration("retry-max-wait"),
+			Jitter:      retryJitter,
+		},
+	}
 
-		err = ErrInvalidResponse
+	if viper.GetBool("no-cache") {
+		return c, nil
+	}
 
-		if resp.StatusCode == http.StatusNotFound {
-			err = ErrNotFound
+	// Without a cache directory, requests are simply not cached.
+	if cache, err := httpCache(); err == nil {
+		c.HTTPClient.Transport = &client.CacheTransport{
+			Base:  c.HTTPClient.Transport,
+			Cache: cache,
 		}
-
-		return nil, fmt.Errorf("%w: %s", err, errMsg)
 	}
 
-	var respData response
+	return c, nil
+}
 
-	if err := json.NewDecoder(resp.Body).Decode(&respData); err != nil {
+// httpCache returns the cache of API responses, kept in the todo_list_client
+// directory of the user cache directory.
+func httpCache() (*client.DiskCache, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
 		return nil, err
 	}
 
-	if len(respData.Results) == 0 {
-		return nil, fmt.Errorf("%w: no results found", ErrNotFound)
-	}
-
-	return respData.Results, nil
+	return &client.DiskCache{Dir: filepath.Join(dir, appName, "http")}, nil
 }
 
-func addItem(url, name string) error {
-	u := fmt.Sprintf("%s/todo", url)
-
-	var body bytes.Buffer
-
-	item := struct {
-		Task string `json:"task"`
-	}{
-		Task: name,
+// newTransport returns the HTTP transport configured with the TLS settings.
+func newTransport() (*http.Transport, error) {
+	opts := client.TLSOptions{
+		CAFile:             viper.GetString("ca-cert"),
+		CertFile:           viper.GetString("client-cert"),
+		KeyFile:            viper.GetString("client-key"),
+		ServerName:         viper.GetString("tls-server-name"),
+		InsecureSkipVerify: viper.GetBool("insecure-skip-verify"),
 	}
 
-	if err := json.NewEncoder(&body).Encode(item); err != nil {
-		return err
+	if opts.InsecureSkipVerify {
+		fmt.Fprintln(
+			os.Stderr,
+			"WARNING: TLS certificate verification is disabled, "+
+				"the connection to the API can be intercepted",
+		)
 	}
 
-	return sendMutatingRequest(
-		u, http.MethodPost, "application/json", http.StatusCreated, &body,
-	)
-}
+	tlsConfig, err := opts.Config()
+	if err != nil {
+		return nil, err
+	}
 
-func completeItem(url string, id int) error {
-	u := fmt.Sprintf("%s/todo/%d?complete", url, id)
+	transport := client.NewTransport()
+	transport.TLSClientConfig = tlsConfig
 
-	return sendMutatingRequest(
-		u, http.MethodPatch, "", http.StatusNoContent, nil,
-	)
+	return transport, nil
 }
 
-func deleteItem(url string, id int) error {
-	u := fmt.Sprintf("%s/todo/%d", url, id)
-
-	return sendMutatingRequest(
-		u, http.MethodDelete, "", http.StatusNoContent, nil,
-	)
-}
+// newAuth returns the credentials for the API at url. They are looked up, in
+// order, in the file given with --token-file, the token setting or the
+// TODO_TOKEN variable, the username and password settings, and the secrets
+// saved by the login command.
+func newAuth(url string) (client.Auth, error) {
+	if path := viper.GetString("token-file"); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read token file: %w", err)
+		}
 
-func sendMutatingRequest(
-	url, method, contentType string, statusCode int, body io.Reader,
-) error {
+		return client.BearerToken(strings.TrimSpace(string(data))), nil
+	}
 
-	req, err := http.NewRequest(method, url, body)
-	if err != nil {
-		return err
+	if token := viper.GetString("token"); token != "" {
+		return client.BearerToken(token), nil
 	}
 
-	if contentType != "" {
-		req.Header.Set("Content-Type", contentType)
+	if username := viper.GetString("username"); username != "" {
+		return client.BasicAuth{
+			Username: username,
+			Password: viper.GetString("password"),
+		}, nil
 	}
 
-	// Send the request.
-	resp, err := newClient().Do(req)
+	store, err := defaultCredentialStore()
 	if err != nil {
-		return err
+		// Without a config directory there are no saved credentials.
+		return nil, nil
 	}
 
-	defer resp.Body.Close()
-
-	if resp.StatusCode != statusCode {
-		msg, err := io.ReadAll(resp.Body)
-		if err != nil {
-			return fmt.Errorf("failed to read body: %w", err)
-		}
-
-		err = ErrInvalidResponse
-
-		if resp.StatusCode == http.StatusNotFound {
-			err = ErrNotFound
-		}
-
-		return fmt.Errorf("%w: %s", err, msg)
+	saved, ok, err := store.get(url)
+	if err != nil || !ok {
+		return nil, err
 	}
 
-	return nil
-}
-
-func newClient() *http.Client {
-	c := &http.Client{
-		Timeout: 10 * time.Second,
+	if saved.Token != "" {
+		return client.BearerToken(saved.Token), nil
 	}
 
-	return c
+	return client.BasicAuth{
+		Username: saved.Username,
+		Password: saved.Password,
+	}, nil
 }
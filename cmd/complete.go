@@ -22,39 +22,198 @@ THE SOFTWARE.
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"os"
-	"strconv"
+	"time"
 
+	"github.com/mycok/todo_list_client/client"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
 
 // completeCmd represents the complete command
 var completeCmd = &cobra.Command{
-	Use:          "complete <itemID>",
-	Short:        "Mark a todo item as complete",
-	Args:         cobra.ExactArgs(1),
+	Use:   "complete <itemID>...",
+	Short: "Mark one or more todo items as complete",
+	Long: `Mark one or more todo items as complete.
+
+Items are given by their ID, or by their position in the list with #N.
+They can be given as separate arguments, comma separated lists and
+inclusive ranges, e.g. "complete 3 5 7-12", "complete 1,4,9" or
+"complete #1-#3". Use the global --parallel flag to send several requests
+at a time.
+
+Completing an item with pending subtasks leaves them pending and prints a
+warning, unless --recursive is given to complete them too.
+
+Completing a recurring item adds its next occurrence, due on the next date
+of its schedule that is not already past. Items that were already complete
+get no new occurrence.
+
+When the API is unreachable, the items are queued in the outbox and
+completed by the sync command, or by the next command that reaches the API.`,
+	Args:         cobra.MinimumNArgs(1),
 	SilenceUsage: true,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		rootURL := viper.GetString("api-root")
 
-		return completeAction(os.Stdout, rootURL, args[0])
+		recursive, _ := cmd.Flags().GetBool("recursive")
+
+		return completeAction(cmd.Context(), os.Stdout, rootURL, args, recursive)
 	},
 }
 
-func completeAction(w io.Writer, url, id string) error {
-	itemID, err := strconv.Atoi(id)
+func completeAction(
+	ctx context.Context, w io.Writer, url string, args []string, recursive bool,
+) error {
+	refs, err := parseIDs(args)
+	if err != nil {
+		return err
+	}
+
+	c, err := newClient(url)
+	if err != nil {
+		return err
+	}
+
+	resumeOutbox(ctx, c)
+
+	ids, items, subtasks, err := completeTargets(ctx, c, refs, recursive)
+	if err != nil {
+		return err
+	}
+
+	ids, err = withSubtasks(ids, subtasks, recursive)
+	if err != nil {
+		return err
+	}
+
+	results, queued, err := batchOrQueue(
+		c, ids, opComplete, func(ids []int) ([]client.Result, error) {
+			return c.CompleteMany(ctx, ids, viper.GetInt("parallel"))
+		},
+	)
 	if err != nil {
-		return fmt.Errorf("%w: item ID must me a number", ErrNotNumber)
+		return err
 	}
 
-	if err := completeItem(url, itemID); err != nil {
+	if err := printQueued(w, queued, "completed"); err != nil {
 		return err
 	}
 
-	return printCompletedItem(w, itemID)
+	err = printResults(w, results, resultsError(results), "completed", printCompletedItem)
+
+	completed := append([]int(nil), queued...)
+
+	for _, r := range results {
+		if r.Err == nil {
+			completed = append(completed, r.ID)
+		}
+	}
+
+	now := time.Now()
+
+	for _, id := range completed {
+		// Items that were done before this command already had their next
+		// occurrence added.
+		item, ok := items[id]
+		if !ok || item.Recurrence == "" || item.Done {
+			continue
+		}
+
+		if nextErr := addNextOccurrence(ctx, w, c, item, now); nextErr != nil {
+			return nextErr
+		}
+	}
+
+	return err
+}
+
+// completeTargets returns the IDs of the items to complete, the items
+// behind them and their subtasks. The full list is only read to look up
+// positions, or to find subtasks at any depth with --recursive. Otherwise
+// the items are fetched one by one, with their direct subtasks, which is
+// enough to warn about the subtasks left pending.
+func completeTargets(
+	ctx context.Context, c *client.Client, refs []itemRef, recursive bool,
+) ([]int, map[int]client.Item, map[int][]client.Item, error) {
+	if !recursive && !hasPositions(refs) {
+		ids, err := resolveRefs(nil, refs)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+
+		items, subtasks, err := lookupItems(ctx, c, ids)
+
+		return ids, items, subtasks, err
+	}
+
+	all, err := listAll(ctx, c)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	ids, err := resolveRefs(all, refs)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	return ids, byRef(all), subtasksOf(all), nil
+}
+
+// withSubtasks adds the pending subtasks of the items in ids before them
+// when recursive is set. Otherwise it warns on stderr about the items whose
+// subtasks are left pending.
+func withSubtasks(
+	ids []int, subtasks map[int][]client.Item, recursive bool,
+) ([]int, error) {
+	if recursive {
+		var all []int
+
+		added := make(map[int]bool)
+
+		for _, id := range ids {
+			for _, s := range append(openSubtasks(subtasks, id), id) {
+				if !added[s] {
+					added[s] = true
+					all = append(all, s)
+				}
+			}
+		}
+
+		return all, nil
+	}
+
+	completing := make(map[int]bool, len(ids))
+
+	for _, id := range ids {
+		completing[id] = true
+	}
+
+	for _, id := range ids {
+		open := 0
+
+		for _, s := range openSubtasks(subtasks, id) {
+			if !completing[s] {
+				open++
+			}
+		}
+
+		if open == 0 {
+			continue
+		}
+
+		if _, err := fmt.Fprintf(
+			stderr, "Warning: item number %d has %d pending subtasks, use --recursive to complete them too\n",
+			id, open,
+		); err != nil {
+			return nil, err
+		}
+	}
+
+	return ids, nil
 }
 
 func printCompletedItem(w io.Writer, id int) error {
@@ -65,4 +224,6 @@ func printCompletedItem(w io.Writer, id int) error {
 
 func init() {
 	rootCmd.AddCommand(completeCmd)
+
+	completeCmd.Flags().Bool("recursive", false, "Also complete the pending subtasks of the items")
 }
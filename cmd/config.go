@@ -0,0 +1,481 @@
+/*
+Copyright © 2022 mycok <github.com/mycok>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	ErrUnknownKey   = errors.New("unknown config key")
+	ErrInvalidValue = errors.New("invalid config value")
+	ErrKeyNotSet    = errors.New("config key not set")
+	ErrConfigExists = errors.New("config file already exists")
+)
+
+// redactedSettings holds the settings that config view hides.
+var redactedSettings = []string{"token", "password"}
+
+// settingParsers validates and converts the settings that are not plain
+// strings. Values are kept in the form they are written in the config file.
+var settingParsers = map[string]func(value string) (interface{}, error){
+	"api-root":             parseURLSetting,
+	"timeout":              parseDurationSetting,
+	"retry-max-wait":       parseDurationSetting,
+	"retries":              parseCountSetting,
+	"parallel":             parseCountSetting,
+	"insecure-skip-verify": parseBoolSetting,
+	"no-cache":             parseBoolSetting,
+	"output":               parseFormatSetting,
+}
+
+// configCmd represents the config command
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "View and edit the config file",
+	Long: `View and edit the config file.
+
+Without --config, the config file is the first one found of
+$XDG_CONFIG_HOME/todo_list_client/config.yaml (~/.config when
+XDG_CONFIG_HOME is not set) and $HOME/.todo_list_client.yaml.
+
+Keys are the names of the global flags, e.g. api-root or timeout, plus
+current-profile. Profile settings are addressed as profiles.<name>.<key>.`,
+}
+
+var configViewCmd = &cobra.Command{
+	Use:          "view",
+	Short:        "Print the config file, with secrets redacted",
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path, err := configFilePath()
+		if err != nil {
+			return err
+		}
+
+		raw, err := cmd.Flags().GetBool("raw")
+		if err != nil {
+			return err
+		}
+
+		return configViewAction(os.Stdout, path, raw)
+	},
+}
+
+var configGetCmd = &cobra.Command{
+	Use:          "get <key>",
+	Short:        "Print the value of a key in the config file",
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path, err := configFilePath()
+		if err != nil {
+			return err
+		}
+
+		return configGetAction(os.Stdout, path, args[0])
+	},
+}
+
+var configSetCmd = &cobra.Command{
+	Use:          "set <key> <value>",
+	Short:        "Set a key in the config file",
+	Args:         cobra.ExactArgs(2),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path, err := configFilePath()
+		if err != nil {
+			return err
+		}
+
+		return configSetAction(os.Stdout, path, args[0], args[1])
+	},
+}
+
+var configUnsetCmd = &cobra.Command{
+	Use:          "unset <key>",
+	Short:        "Remove a key from the config file",
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path, err := configFilePath()
+		if err != nil {
+			return err
+		}
+
+		return configUnsetAction(os.Stdout, path, args[0])
+	},
+}
+
+var configPathCmd = &cobra.Command{
+	Use:          "path",
+	Short:        "Print the path of the config file",
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path, err := configFilePath()
+		if err != nil {
+			return err
+		}
+
+		_, err = fmt.Fprintln(os.Stdout, path)
+
+		return err
+	},
+}
+
+var configInitCmd = &cobra.Command{
+	Use:          "init",
+	Short:        "Create a config file with the default settings",
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path, err := configFilePath()
+		if err != nil {
+			return err
+		}
+
+		force, err := cmd.Flags().GetBool("force")
+		if err != nil {
+			return err
+		}
+
+		return configInitAction(os.Stdout, path, force)
+	},
+}
+
+func configViewAction(w io.Writer, path string, raw bool) error {
+	settings, err := readConfigFile(path)
+	if err != nil {
+		return err
+	}
+
+	if !raw {
+		redactSettings(settings)
+	}
+
+	return writeYAML(w, settings)
+}
+
+// redactSettings hides the secrets in settings and in all of its profiles.
+func redactSettings(settings map[string]interface{}) {
+	for _, key := range redactedSettings {
+		if _, ok := settings[key]; ok {
+			settings[key] = "REDACTED"
+		}
+	}
+
+	for _, profile := range profilesIn(settings) {
+		redactSettings(profile)
+	}
+}
+
+func configGetAction(w io.Writer, path, key string) error {
+	settings, err := readConfigFile(path)
+	if err != nil {
+		return err
+	}
+
+	parent, name, err := lookupKey(settings, key, false)
+	if err != nil {
+		return err
+	}
+
+	value, ok := parent[name]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrKeyNotSet, key)
+	}
+
+	if m, ok := value.(map[string]interface{}); ok {
+		return writeYAML(w, m)
+	}
+
+	_, err = fmt.Fprintln(w, value)
+
+	return err
+}
+
+func configSetAction(w io.Writer, path, key, value string) error {
+	settings, err := readConfigFile(path)
+	if err != nil {
+		return err
+	}
+
+	parent, name, err := lookupKey(settings, key, true)
+	if err != nil {
+		return err
+	}
+
+	parsed, err := parseSetting(name, value)
+	if err != nil {
+		return err
+	}
+
+	if name == "current-profile" {
+		if _, ok := profilesIn(settings)[value]; !ok {
+			return fmt.Errorf("%w: %q", ErrProfileNotFound, value)
+		}
+	}
+
+	parent[name] = parsed
+
+	if err := validateConfig(settings); err != nil {
+		return err
+	}
+
+	if err := writeConfigFile(path, settings); err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "Set %s in %s\n", key, path)
+
+	return err
+}
+
+func configUnsetAction(w io.Writer, path, key string) error {
+	settings, err := readConfigFile(path)
+	if err != nil {
+		return err
+	}
+
+	parent, name, err := lookupKey(settings, key, false)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := parent[name]; !ok {
+		return fmt.Errorf("%w: %s", ErrKeyNotSet, key)
+	}
+
+	delete(parent, name)
+
+	if err := writeConfigFile(path, settings); err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "Unset %s in %s\n", key, path)
+
+	return err
+}
+
+func configInitAction(w io.Writer, path string, force bool) error {
+	if _, err := os.Stat(path); err == nil && !force {
+		return fmt.Errorf("%w: %s, use --force to replace it", ErrConfigExists, path)
+	}
+
+	settings := map[string]interface{}{
+		"api-root": rootCmd.PersistentFlags().Lookup("api-root").DefValue,
+	}
+
+	if err := createConfigFile(path, settings); err != nil {
+		return err
+	}
+
+	_, err := fmt.Fprintf(w, "Created %s\n", path)
+
+	return err
+}
+
+// lookupKey resolves a dotted key such as api-root or profiles.dev.timeout
+// to the map holding it and its last segment. With create set, a missing
+// profile is added to settings.
+func lookupKey(
+	settings map[string]interface{}, key string, create bool,
+) (map[string]interface{}, string, error) {
+	parts := strings.Split(key, ".")
+
+	switch {
+	case len(parts) == 1 && (parts[0] == "current-profile" || isProfileKey(parts[0])):
+		return settings, parts[0], nil
+
+	case len(parts) == 2 && parts[0] == "profiles" && parts[1] != "":
+		profiles, _ := settings["profiles"].(map[string]interface{})
+		if profiles == nil {
+			return nil, "", fmt.Errorf("%w: %s", ErrKeyNotSet, key)
+		}
+
+		return profiles, parts[1], nil
+
+	case len(parts) == 3 && parts[0] == "profiles" && parts[1] != "" &&
+		isProfileKey(parts[2]):
+		profiles, _ := settings["profiles"].(map[string]interface{})
+		if profiles == nil {
+			profiles = make(map[string]interface{})
+		}
+
+		profile, _ := profiles[parts[1]].(map[string]interface{})
+		if profile == nil {
+			if !create {
+				return nil, "", fmt.Errorf("%w: %q", ErrProfileNotFound, parts[1])
+			}
+
+			profile = make(map[string]interface{})
+			profiles[parts[1]] = profile
+			settings["profiles"] = profiles
+		}
+
+		return profile, parts[2], nil
+	}
+
+	return nil, "", fmt.Errorf("%w: %s", ErrUnknownKey, key)
+}
+
+func isProfileKey(key string) bool {
+	for _, k := range profileKeys {
+		if k == key {
+			return true
+		}
+	}
+
+	return false
+}
+
+// parseSetting validates value for key and converts it to the type it is
+// stored as.
+func parseSetting(key, value string) (interface{}, error) {
+	parse, ok := settingParsers[key]
+	if !ok {
+		return value, nil
+	}
+
+	parsed, err := parse(value)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %s", ErrInvalidValue, key, err)
+	}
+
+	return parsed, nil
+}
+
+// validateConfig checks that settings only holds known keys with valid
+// values.
+func validateConfig(settings map[string]interface{}) error {
+	for key, value := range settings {
+		switch key {
+		case "current-profile":
+			continue
+
+		case "profiles":
+			profiles, ok := value.(map[string]interface{})
+			if !ok && value != nil {
+				return fmt.Errorf("%w: profiles must be a map", ErrInvalidValue)
+			}
+
+			for name, p := range profiles {
+				profile, ok := p.(map[string]interface{})
+				if !ok && p != nil {
+					return fmt.Errorf(
+						"%w: profiles.%s must be a map", ErrInvalidValue, name,
+					)
+				}
+
+				for k, v := range profile {
+					if err := validateSetting("profiles."+name+"."+k, k, v); err != nil {
+						return err
+					}
+				}
+			}
+
+		default:
+			if err := validateSetting(key, key, value); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func validateSetting(path, key string, value interface{}) error {
+	if !isProfileKey(key) {
+		return fmt.Errorf("%w: %s", ErrUnknownKey, path)
+	}
+
+	_, err := parseSetting(key, fmt.Sprint(value))
+
+	return err
+}
+
+func parseURLSetting(value string) (interface{}, error) {
+	u, err := url.Parse(value)
+	if err != nil {
+		return nil, err
+	}
+
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("%q is not an http(s) URL", value)
+	}
+
+	return value, nil
+}
+
+func parseDurationSetting(value string) (interface{}, error) {
+	if _, err := time.ParseDuration(value); err != nil {
+		return nil, err
+	}
+
+	return value, nil
+}
+
+func parseCountSetting(value string) (interface{}, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return nil, fmt.Errorf("%q is not a non-negative number", value)
+	}
+
+	return n, nil
+}
+
+func parseBoolSetting(value string) (interface{}, error) {
+	return strconv.ParseBool(value)
+}
+
+func parseFormatSetting(value string) (interface{}, error) {
+	if _, err := getFormatter(value); err != nil {
+		return nil, err
+	}
+
+	return value, nil
+}
+
+func init() {
+	rootCmd.AddCommand(configCmd)
+
+	configCmd.AddCommand(configViewCmd)
+	configCmd.AddCommand(configGetCmd)
+	configCmd.AddCommand(configSetCmd)
+	configCmd.AddCommand(configUnsetCmd)
+	configCmd.AddCommand(configPathCmd)
+	configCmd.AddCommand(configInitCmd)
+
+	configViewCmd.Flags().Bool("raw", false, "Show the secrets instead of redacting them")
+	configInitCmd.Flags().Bool("force", false, "Replace an existing config file")
+}
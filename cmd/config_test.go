@@ -0,0 +1,221 @@
+//go:build !integration
+// +build !integration
+
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestConfigSearchPaths(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", "")
+
+	paths, err := configSearchPaths()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		filepath.Join(home, ".config", "todo_list_client", "config.yaml"),
+		filepath.Join(home, ".todo_list_client.yaml"),
+	}
+
+	if strings.Join(expected, "\n") != strings.Join(paths, "\n") {
+		t.Errorf("Expected paths: %q, but got: %q instead", expected, paths)
+	}
+
+	if path, err := findConfigFile(); err != nil || path != "" {
+		t.Errorf("Expected no config file, but got: %q, %v instead", path, err)
+	}
+
+	if err := os.WriteFile(expected[1], []byte("api-root: http://home:8080\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if path, err := findConfigFile(); err != nil || path != expected[1] {
+		t.Errorf("Expected config file: %q, but got: %q, %v instead", expected[1], path, err)
+	}
+
+	xdg := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", xdg)
+
+	xdgPath := filepath.Join(xdg, "todo_list_client", "config.yaml")
+
+	if err := writeConfigFile(xdgPath, map[string]interface{}{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if path, err := findConfigFile(); err != nil || path != xdgPath {
+		t.Errorf("Expected config file: %q, but got: %q, %v instead", xdgPath, path, err)
+	}
+}
+
+func TestConfigActions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+
+	var out bytes.Buffer
+
+	if err := configInitAction(&out, path, false); err != nil {
+		t.Fatalf("Expected no error, but got: %q instead", err)
+	}
+
+	err := configInitAction(&out, path, false)
+	if !errors.Is(err, ErrConfigExists) {
+		t.Errorf("Expected error: %q, but got: %q instead", ErrConfigExists, err)
+	}
+
+	testCases := []struct {
+		name   string
+		key    string
+		value  string
+		expErr error
+	}{
+		{name: "URL", key: "api-root", value: "https://todo.example.com"},
+		{name: "Duration", key: "timeout", value: "5s"},
+		{name: "Secret", key: "token", value: "s3cr3t"},
+		{name: "ProfileKey", key: "profiles.dev.api-root", value: "http://dev:8080"},
+		{name: "ProfilePassword", key: "profiles.dev.password", value: "hunter2"},
+		{name: "CurrentProfile", key: "current-profile", value: "dev"},
+		{name: "UnknownKey", key: "colour", value: "blue", expErr: ErrUnknownKey},
+		{name: "UnknownProfileKey", key: "profiles.dev.colour", value: "blue", expErr: ErrUnknownKey},
+		{name: "NoScheme", key: "api-root", value: "todo.example.com", expErr: ErrInvalidValue},
+		{name: "BadScheme", key: "api-root", value: "ftp://todo.example.com", expErr: ErrInvalidValue},
+		{name: "BadDuration", key: "retry-max-wait", value: "soon", expErr: ErrInvalidValue},
+		{name: "BadCount", key: "parallel", value: "-1", expErr: ErrInvalidValue},
+		{name: "BadFormat", key: "output", value: "xml", expErr: ErrInvalidValue},
+		{name: "MissingProfile", key: "current-profile", value: "prod", expErr: ErrProfileNotFound},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := configSetAction(&out, path, tc.key, tc.value)
+
+			if tc.expErr != nil {
+				if !errors.Is(err, tc.expErr) {
+					t.Errorf("Expected error: %q, but got: %q instead", tc.expErr, err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Expected no error, but got: %q instead", err)
+			}
+
+			out.Reset()
+
+			if err := configGetAction(&out, path, tc.key); err != nil {
+				t.Fatalf("Expected no error, but got: %q instead", err)
+			}
+
+			if tc.value+"\n" != out.String() {
+				t.Errorf("Expected value: %q, but got: %q instead", tc.value+"\n", out.String())
+			}
+		})
+	}
+
+	out.Reset()
+
+	if err := configViewAction(&out, path, false); err != nil {
+		t.Fatalf("Expected no error, but got: %q instead", err)
+	}
+
+	for _, secret := range []string{"s3cr3t", "hunter2"} {
+		if strings.Contains(out.String(), secret) {
+			t.Errorf("Expected %q to be redacted, but got: %s", secret, out.String())
+		}
+	}
+
+	out.Reset()
+
+	if err := configViewAction(&out, path, true); err != nil {
+		t.Fatalf("Expected no error, but got: %q instead", err)
+	}
+
+	if !strings.Contains(out.String(), "s3cr3t") {
+		t.Errorf("Expected the raw token, but got: %s", out.String())
+	}
+
+	if err := configUnsetAction(&out, path, "timeout"); err != nil {
+		t.Fatalf("Expected no error, but got: %q instead", err)
+	}
+
+	err = configGetAction(&out, path, "timeout")
+	if !errors.Is(err, ErrKeyNotSet) {
+		t.Errorf("Expected error: %q, but got: %q instead", ErrKeyNotSet, err)
+	}
+
+	err = configUnsetAction(&out, path, "timeout")
+	if !errors.Is(err, ErrKeyNotSet) {
+		t.Errorf("Expected error: %q, but got: %q instead", ErrKeyNotSet, err)
+	}
+
+	if err := configInitAction(&out, path, true); err != nil {
+		t.Fatalf("Expected no error, but got: %q instead", err)
+	}
+
+	settings, err := readConfigFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(settings) != 1 || settings["api-root"] != "http://localhost:8080" {
+		t.Errorf("Expected only the default api-root, but got: %v instead", settings)
+	}
+}
+
+func TestWriteConfigFileKeepsComments(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+
+	data := "# Todo API settings\n" +
+		"timeout: 5s # slow network\n" +
+		"api-root: http://localhost:8080\n" +
+		"retries: 3\n" +
+		"profiles:\n" +
+		"  # Staging server\n" +
+		"  dev:\n" +
+		"    api-root: http://dev:8080\n"
+
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+
+	if err := configSetAction(&out, path, "timeout", "10s"); err != nil {
+		t.Fatalf("Expected no error, but got: %q instead", err)
+	}
+
+	if err := configUnsetAction(&out, path, "retries"); err != nil {
+		t.Fatalf("Expected no error, but got: %q instead", err)
+	}
+
+	if err := configSetAction(&out, path, "profiles.dev.token", "s3cr3t"); err != nil {
+		t.Fatalf("Expected no error, but got: %q instead", err)
+	}
+
+	expected := "# Todo API settings\n" +
+		"timeout: 10s # slow network\n" +
+		"api-root: http://localhost:8080\n" +
+		"profiles:\n" +
+		"  # Staging server\n" +
+		"  dev:\n" +
+		"    api-root: http://dev:8080\n" +
+		"    token: s3cr3t\n"
+
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected != string(got) {
+		t.Errorf("Expected config file:\n%s\nbut got:\n%s\ninstead", expected, got)
+	}
+}
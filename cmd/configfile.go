@@ -0,0 +1,219 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+
+	"github.com/mycok/todo_list_client/internal/fsutil"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+// configSearchPaths returns the locations searched for a config file when
+// --config is not given, in order of preference:
+// $XDG_CONFIG_HOME/todo_list_client/config.yaml, with XDG_CONFIG_HOME
+// defaulting to ~/.config, then ~/.todo_list_client.yaml.
+func configSearchPaths() ([]string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, err
+	}
+
+	configHome := os.Getenv("XDG_CONFIG_HOME")
+	if configHome == "" {
+		configHome = filepath.Join(home, ".config")
+	}
+
+	return []string{
+		filepath.Join(configHome, appName, "config.yaml"),
+		filepath.Join(home, "."+appName+".yaml"),
+	}, nil
+}
+
+// findConfigFile returns the first config file that exists in the search
+// paths, or an empty path if there is none.
+func findConfigFile() (string, error) {
+	paths, err := configSearchPaths()
+	if err != nil {
+		return "", err
+	}
+
+	for _, path := range paths {
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+
+	return "", nil
+}
+
+// configFilePath returns the config file that commands editing the
+// configuration write to: the file in use, or the preferred location if
+// there is none yet.
+func configFilePath() (string, error) {
+	if path := viper.ConfigFileUsed(); path != "" {
+		return path, nil
+	}
+
+	if cfgFile != "" {
+		return cfgFile, nil
+	}
+
+	paths, err := configSearchPaths()
+	if err != nil {
+		return "", err
+	}
+
+	return paths[0], nil
+}
+
+// readConfigFile returns the settings in the YAML file at path, or no
+// settings if the file does not exist.
+func readConfigFile(path string) (map[string]interface{}, error) {
+	settings := make(map[string]interface{})
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return settings, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := yaml.Unmarshal(data, &settings); err != nil {
+		return nil, err
+	}
+
+	if settings == nil {
+		settings = make(map[string]interface{})
+	}
+
+	return settings, nil
+}
+
+// writeConfigFile updates the file at path to hold settings. The YAML tree
+// of the file is edited in place, so that the comments and the order of the
+// keys it had are kept, and new keys are added at the end of their map.
+// The file can hold credentials, so only the user can read it.
+func writeConfigFile(path string, settings map[string]interface{}) error {
+	var doc yaml.Node
+
+	data, err := os.ReadFile(path)
+
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+	case err != nil:
+		return err
+	default:
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return err
+		}
+	}
+
+	if doc.Kind != yaml.DocumentNode {
+		doc = yaml.Node{Kind: yaml.DocumentNode}
+	}
+
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		doc.Content = []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}
+	}
+
+	if err := updateMapping(doc.Content[0], settings); err != nil {
+		return err
+	}
+
+	return createConfigFile(path, &doc)
+}
+
+// createConfigFile replaces the file at path with v, as YAML.
+func createConfigFile(path string, v interface{}) error {
+	var data bytes.Buffer
+
+	if err := writeYAML(&data, v); err != nil {
+		return err
+	}
+
+	return fsutil.WriteFileAtomic(path, data.Bytes(), 0o600)
+}
+
+// updateMapping makes the mapping node m hold values: keys missing from
+// values are removed, the others are updated where their value changed,
+// and new keys are appended in alphabetical order.
+func updateMapping(m *yaml.Node, values map[string]interface{}) error {
+	seen := make(map[string]bool, len(values))
+	content := make([]*yaml.Node, 0, len(m.Content))
+
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		key, node := m.Content[i], m.Content[i+1]
+
+		value, ok := values[key.Value]
+		if !ok || seen[key.Value] {
+			continue
+		}
+
+		seen[key.Value] = true
+
+		if err := updateNode(node, value); err != nil {
+			return err
+		}
+
+		content = append(content, key, node)
+	}
+
+	keys := make([]string, 0, len(values))
+
+	for key := range values {
+		if !seen[key] {
+			keys = append(keys, key)
+		}
+	}
+
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		node := new(yaml.Node)
+
+		if err := node.Encode(values[key]); err != nil {
+			return err
+		}
+
+		content = append(content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, node)
+	}
+
+	m.Content = content
+
+	return nil
+}
+
+// updateNode makes n hold value, leaving it untouched if it already does and
+// keeping its comments if it does not.
+func updateNode(n *yaml.Node, value interface{}) error {
+	if values, ok := value.(map[string]interface{}); ok && n.Kind == yaml.MappingNode {
+		return updateMapping(n, values)
+	}
+
+	var current interface{}
+
+	if err := n.Decode(&current); err == nil && reflect.DeepEqual(current, value) {
+		return nil
+	}
+
+	var updated yaml.Node
+
+	if err := updated.Encode(value); err != nil {
+		return err
+	}
+
+	updated.HeadComment = n.HeadComment
+	updated.LineComment = n.LineComment
+	updated.FootComment = n.FootComment
+	*n = updated
+
+	return nil
+}
@@ -0,0 +1,349 @@
+/*
+Copyright © 2022 mycok <github.com/mycok>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var (
+	ErrProfileNotFound = errors.New("profile not found")
+	ErrProfileExists   = errors.New("profile already exists")
+	ErrNoProfile       = errors.New("no profile in use")
+)
+
+// profileKeys holds the settings that a profile can set. Each of them is
+// also a global flag.
+var profileKeys = []string{
+	"api-root",
+	"list",
+	"token",
+	"token-file",
+	"username",
+	"password",
+	"ca-cert",
+	"client-cert",
+	"client-key",
+	"tls-server-name",
+	"insecure-skip-verify",
+	"timeout",
+	"retries",
+	"retry-max-wait",
+	"no-cache",
+	"parallel",
+	"output",
+}
+
+// contextCmd represents the context command
+var contextCmd = &cobra.Command{
+	Use:   "context",
+	Short: "Manage the named profiles of API servers",
+	Long: `Manage the named profiles of API servers.
+
+Profiles are kept under the "profiles" key of the config file and can set
+any of: ` + strings.Join(profileKeys, ", ") + `.
+The profile in use is the one given with --profile, or else the one
+selected with "context use". Flags and TODO_* environment variables take
+precedence over the profile settings.`,
+}
+
+var contextUseCmd = &cobra.Command{
+	Use:          "use <name>",
+	Short:        "Make a profile the one in use",
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path, err := configFilePath()
+		if err != nil {
+			return err
+		}
+
+		return contextUseAction(os.Stdout, path, args[0])
+	},
+}
+
+var contextListCmd = &cobra.Command{
+	Use:          "list",
+	Short:        "List the profiles",
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path, err := configFilePath()
+		if err != nil {
+			return err
+		}
+
+		return contextListAction(os.Stdout, path, activeProfile())
+	},
+}
+
+var contextCurrentCmd = &cobra.Command{
+	Use:          "current",
+	Short:        "Print the name of the profile in use",
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := activeProfile()
+		if name == "" {
+			return ErrNoProfile
+		}
+
+		_, err := fmt.Fprintln(os.Stdout, name)
+
+		return err
+	},
+}
+
+var contextAddCmd = &cobra.Command{
+	Use:   "add <name>",
+	Short: "Add a profile from the global flags",
+	Long: `Add a profile holding the API root and every other profile setting
+given as a global flag, e.g.:
+
+  todo_list_client context add staging --api-root https://staging.example.com --timeout 5s`,
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path, err := configFilePath()
+		if err != nil {
+			return err
+		}
+
+		profile := map[string]interface{}{
+			"api-root": viper.GetString("api-root"),
+		}
+
+		for _, key := range profileKeys {
+			if f := cmd.Flags().Lookup(key); f != nil && f.Changed {
+				profile[key] = viper.Get(key)
+			}
+		}
+
+		return contextAddAction(os.Stdout, path, args[0], profile)
+	},
+}
+
+var contextRemoveCmd = &cobra.Command{
+	Use:          "remove <name>",
+	Short:        "Remove a profile",
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path, err := configFilePath()
+		if err != nil {
+			return err
+		}
+
+		return contextRemoveAction(os.Stdout, path, args[0])
+	},
+}
+
+// activeProfile returns the name of the profile in use, if any.
+func activeProfile() string {
+	if name := viper.GetString("profile"); name != "" {
+		return name
+	}
+
+	return viper.GetString("current-profile")
+}
+
+// profiles returns the profiles defined in settings.
+func profilesIn(settings map[string]interface{}) map[string]map[string]interface{} {
+	all := make(map[string]map[string]interface{})
+
+	raw, _ := settings["profiles"].(map[string]interface{})
+
+	for name, p := range raw {
+		profile, _ := p.(map[string]interface{})
+		if profile == nil {
+			profile = make(map[string]interface{})
+		}
+
+		all[name] = profile
+	}
+
+	return all
+}
+
+// applyProfile makes the settings of the profile in use take effect for
+// every flag and environment variable that was not set.
+func applyProfile(path string) error {
+	name := activeProfile()
+	if name == "" {
+		return nil
+	}
+
+	settings, err := readConfigFile(path)
+	if err != nil {
+		return err
+	}
+
+	profile, ok := profilesIn(settings)[name]
+	if !ok {
+		return fmt.Errorf("%w: %q", ErrProfileNotFound, name)
+	}
+
+	for key, value := range profile {
+		if f := rootCmd.PersistentFlags().Lookup(key); f != nil && f.Changed {
+			continue
+		}
+
+		if _, ok := os.LookupEnv(envName(key)); ok {
+			continue
+		}
+
+		viper.Set(key, value)
+	}
+
+	return nil
+}
+
+// envName returns the environment variable that overrides the setting key.
+func envName(key string) string {
+	return "TODO_" + strings.ToUpper(strings.ReplaceAll(key, "-", "_"))
+}
+
+func contextUseAction(w io.Writer, path, name string) error {
+	settings, err := readConfigFile(path)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := profilesIn(settings)[name]; !ok {
+		return fmt.Errorf("%w: %q", ErrProfileNotFound, name)
+	}
+
+	settings["current-profile"] = name
+
+	if err := writeConfigFile(path, settings); err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "Switched to profile %q\n", name)
+
+	return err
+}
+
+func contextListAction(w io.Writer, path, current string) error {
+	settings, err := readConfigFile(path)
+	if err != nil {
+		return err
+	}
+
+	all := profilesIn(settings)
+
+	names := make([]string, 0, len(all))
+	for name := range all {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	tw := tabwriter.NewWriter(w, 3, 2, 2, ' ', 0)
+
+	fmt.Fprintln(tw, "CURRENT\tNAME\tAPI ROOT")
+
+	for _, name := range names {
+		marker := ""
+		if name == current {
+			marker = "*"
+		}
+
+		fmt.Fprintf(tw, "%s\t%s\t%v\n", marker, name, all[name]["api-root"])
+	}
+
+	return tw.Flush()
+}
+
+func contextAddAction(
+	w io.Writer, path, name string, profile map[string]interface{},
+) error {
+	settings, err := readConfigFile(path)
+	if err != nil {
+		return err
+	}
+
+	all, _ := settings["profiles"].(map[string]interface{})
+	if all == nil {
+		all = make(map[string]interface{})
+	}
+
+	if _, ok := all[name]; ok {
+		return fmt.Errorf("%w: %q", ErrProfileExists, name)
+	}
+
+	all[name] = profile
+	settings["profiles"] = all
+
+	if err := writeConfigFile(path, settings); err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "Added profile %q to %s\n", name, path)
+
+	return err
+}
+
+func contextRemoveAction(w io.Writer, path, name string) error {
+	settings, err := readConfigFile(path)
+	if err != nil {
+		return err
+	}
+
+	all, _ := settings["profiles"].(map[string]interface{})
+	if _, ok := all[name]; !ok {
+		return fmt.Errorf("%w: %q", ErrProfileNotFound, name)
+	}
+
+	delete(all, name)
+
+	if settings["current-profile"] == name {
+		delete(settings, "current-profile")
+	}
+
+	if err := writeConfigFile(path, settings); err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "Removed profile %q\n", name)
+
+	return err
+}
+
+func init() {
+	rootCmd.AddCommand(contextCmd)
+
+	contextCmd.AddCommand(contextUseCmd)
+	contextCmd.AddCommand(contextListCmd)
+	contextCmd.AddCommand(contextCurrentCmd)
+	contextCmd.AddCommand(contextAddCmd)
+	contextCmd.AddCommand(contextRemoveCmd)
+}
@@ -0,0 +1,133 @@
+//go:build !integration
+// +build !integration
+
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestContextActions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+
+	var out bytes.Buffer
+
+	profiles := map[string]map[string]interface{}{
+		"dev":  {"api-root": "http://dev:8080", "timeout": "5s"},
+		"prod": {"api-root": "https://prod.example.com", "output": "json"},
+	}
+
+	for _, name := range []string{"dev", "prod"} {
+		if err := contextAddAction(&out, path, name, profiles[name]); err != nil {
+			t.Fatalf("Expected no error, but got: %q instead", err)
+		}
+	}
+
+	err := contextAddAction(&out, path, "dev", profiles["dev"])
+	if !errors.Is(err, ErrProfileExists) {
+		t.Errorf("Expected error: %q, but got: %q instead", ErrProfileExists, err)
+	}
+
+	if err := contextUseAction(&out, path, "prod"); err != nil {
+		t.Fatalf("Expected no error, but got: %q instead", err)
+	}
+
+	err = contextUseAction(&out, path, "staging")
+	if !errors.Is(err, ErrProfileNotFound) {
+		t.Errorf("Expected error: %q, but got: %q instead", ErrProfileNotFound, err)
+	}
+
+	out.Reset()
+
+	if err := contextListAction(&out, path, "prod"); err != nil {
+		t.Fatalf("Expected no error, but got: %q instead", err)
+	}
+
+	expectedOutput := "CURRENT  NAME  API ROOT\n" +
+		"         dev   http://dev:8080\n" +
+		"*        prod  https://prod.example.com\n"
+
+	if expectedOutput != out.String() {
+		t.Errorf(
+			"Expected output: %s, but got: %s instead",
+			expectedOutput,
+			out.String(),
+		)
+	}
+
+	if err := contextRemoveAction(&out, path, "prod"); err != nil {
+		t.Fatalf("Expected no error, but got: %q instead", err)
+	}
+
+	settings, err := readConfigFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := settings["current-profile"]; ok {
+		t.Error("Expected the removed profile to no longer be in use")
+	}
+
+	if _, ok := profilesIn(settings)["prod"]; ok {
+		t.Error("Expected the prod profile to be removed")
+	}
+}
+
+func TestApplyProfile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+
+	err := contextAddAction(&bytes.Buffer{}, path, "dev", map[string]interface{}{
+		"api-root": "http://dev:8080",
+		"output":   "yaml",
+		"retries":  5,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Flags win over the profile settings.
+	if err := rootCmd.PersistentFlags().Set("output", "csv"); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("TODO_RETRIES", "1")
+	viper.Set("profile", "dev")
+
+	defer func() {
+		f := rootCmd.PersistentFlags().Lookup("output")
+		f.Value.Set(f.DefValue)
+		f.Changed = false
+
+		// Viper cannot unset a value, restore the flag defaults instead.
+		for _, key := range []string{"profile", "api-root", "output", "retries"} {
+			viper.Set(key, rootCmd.PersistentFlags().Lookup(key).DefValue)
+		}
+	}()
+
+	if err := applyProfile(path); err != nil {
+		t.Fatalf("Expected no error, but got: %q instead", err)
+	}
+
+	if root := viper.GetString("api-root"); root != "http://dev:8080" {
+		t.Errorf("Expected api-root: http://dev:8080, but got: %s instead", root)
+	}
+
+	if output := viper.GetString("output"); output != "csv" {
+		t.Errorf("Expected output: csv, but got: %s instead", output)
+	}
+
+	if viper.IsSet("retries") && viper.GetInt("retries") == 5 {
+		t.Error("Expected the environment to win over the profile")
+	}
+
+	viper.Set("profile", "staging")
+
+	if err := applyProfile(path); !errors.Is(err, ErrProfileNotFound) {
+		t.Errorf("Expected error: %q, but got: %q instead", ErrProfileNotFound, err)
+	}
+}
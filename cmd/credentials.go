@@ -0,0 +1,174 @@
+package cmd
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/mycok/todo_list_client/internal/fsutil"
+)
+
+const (
+	appName            = "todo_list_client"
+	credentialsFile    = "credentials"
+	credentialsKeyFile = "credentials.key"
+)
+
+// storedCredentials is the secret saved by the login command for one API.
+type storedCredentials struct {
+	Token    string `json:"token,omitempty"`
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+}
+
+// credentialStore keeps the secrets saved by the login command, keyed by API
+// root, in a file encrypted with AES-GCM. The key sits in another file of
+// the same directory, so this only obfuscates the secrets at rest: they do
+// not show in plain text in the file or in a copy of it alone, but anyone
+// who can read the directory, as the user, can decrypt them. Both files are
+// only readable by the user.
+type credentialStore struct {
+	dir string
+}
+
+func defaultCredentialStore() (*credentialStore, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return nil, err
+	}
+
+	return &credentialStore{dir: filepath.Join(dir, appName)}, nil
+}
+
+func (s *credentialStore) get(apiRoot string) (storedCredentials, bool, error) {
+	all, err := s.load()
+	if err != nil {
+		return storedCredentials{}, false, err
+	}
+
+	c, ok := all[apiRoot]
+
+	return c, ok, nil
+}
+
+func (s *credentialStore) set(apiRoot string, c storedCredentials) error {
+	all, err := s.load()
+	if err != nil {
+		return err
+	}
+
+	all[apiRoot] = c
+
+	return s.save(all)
+}
+
+// remove deletes the secret saved for apiRoot and reports whether there was
+// one.
+func (s *credentialStore) remove(apiRoot string) (bool, error) {
+	all, err := s.load()
+	if err != nil {
+		return false, err
+	}
+
+	if _, ok := all[apiRoot]; !ok {
+		return false, nil
+	}
+
+	delete(all, apiRoot)
+
+	return true, s.save(all)
+}
+
+func (s *credentialStore) load() (map[string]storedCredentials, error) {
+	all := make(map[string]storedCredentials)
+
+	data, err := os.ReadFile(filepath.Join(s.dir, credentialsFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return all, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	gcm, err := s.cipher(false)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) < gcm.NonceSize() {
+		return nil, fmt.Errorf("credentials file is corrupted")
+	}
+
+	nonce, sealed := data[:gcm.NonceSize()], data[gcm.NonceSize():]
+
+	plain, err := gcm.Open(nil, nonce, sealed, nil)
+	if err != nil {
+		return nil, fmt.Errorf("cannot decrypt credentials: %w", err)
+	}
+
+	if err := json.Unmarshal(plain, &all); err != nil {
+		return nil, err
+	}
+
+	return all, nil
+}
+
+func (s *credentialStore) save(all map[string]storedCredentials) error {
+	plain, err := json.Marshal(all)
+	if err != nil {
+		return err
+	}
+
+	gcm, err := s.cipher(true)
+	if err != nil {
+		return err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return err
+	}
+
+	return fsutil.WriteFileAtomic(
+		filepath.Join(s.dir, credentialsFile),
+		gcm.Seal(nonce, nonce, plain, nil),
+		0o600,
+	)
+}
+
+// cipher returns the AES-GCM cipher for the store, generating its key first
+// if create is set and there is none yet.
+func (s *credentialStore) cipher(create bool) (cipher.AEAD, error) {
+	path := filepath.Join(s.dir, credentialsKeyFile)
+
+	key, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) && create {
+		key = make([]byte, 32)
+
+		if _, err := io.ReadFull(rand.Reader, key); err != nil {
+			return nil, err
+		}
+
+		err = fsutil.WriteFileAtomic(path, key, 0o600)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
@@ -22,39 +22,92 @@ THE SOFTWARE.
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"os"
-	"strconv"
 
+	"github.com/mycok/todo_list_client/client"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
 
 // delCmd represents the del command
 var delCmd = &cobra.Command{
-	Use:          "del <itemID>",
-	Short:        "Delete a todo item",
+	Use:   "del <itemID>...",
+	Short: "Delete one or more todo items",
+	Long: `Delete one or more todo items.
+
+Items are given by their ID, or by their position in the list with #N.
+They can be given as separate arguments, comma separated lists and
+inclusive ranges, e.g. "del 3 5 7-12", "del 1,4,9" or "del #2". Positions
+are looked up once, before the first delete. Items are deleted from the
+highest ID to the lowest, up to --parallel at a time. On servers that
+identify items by their position, they are deleted one at a time, so that
+the positions of the remaining items do not shift during the batch.
+
+When the API is unreachable, the items are queued in the outbox and
+deleted by the sync command, or by the next command that reaches the API.`,
 	SilenceUsage: true,
-	Args:         cobra.ExactArgs(1),
+	Args:         cobra.MinimumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		rootURL := viper.GetString("api-root")
 
-		return deleteAction(os.Stdout, rootURL, args[0])
+		return deleteAction(cmd.Context(), os.Stdout, rootURL, args)
 	},
 }
 
-func deleteAction(w io.Writer, url string, id string) error {
-	itemID, err := strconv.Atoi(id)
+func deleteAction(
+	ctx context.Context, w io.Writer, url string, args []string,
+) error {
+	refs, err := parseIDs(args)
+	if err != nil {
+		return err
+	}
+
+	c, err := newClient(url)
+	if err != nil {
+		return err
+	}
+
+	resumeOutbox(ctx, c)
+
+	ids, err := resolveIDs(ctx, c, refs)
 	if err != nil {
-		return fmt.Errorf("%w: item ID must me a number", ErrNotNumber)
+		return err
+	}
+
+	results, queued, err := batchOrQueue(
+		c, descending(ids), opDelete, func(ids []int) ([]client.Result, error) {
+			return c.DeleteMany(ctx, ids, deleteWorkers(ctx, c, ids))
+		},
+	)
+	if err != nil {
+		return err
 	}
 
-	if err := deleteItem(url, itemID); err != nil {
+	if err := printQueued(w, queued, "deleted"); err != nil {
 		return err
 	}
 
-	return printDeletedItem(w, itemID)
+	return printResults(w, results, resultsError(results), "deleted", printDeletedItem)
+}
+
+// deleteWorkers returns the number of deletes to run at once. Servers that
+// do not send IDs get one at a time, since every delete shifts the position
+// of the items after it. The first item tells which kind of server it is.
+func deleteWorkers(ctx context.Context, c *client.Client, ids []int) int {
+	parallel := viper.GetInt("parallel")
+	if parallel <= 1 || len(ids) <= 1 {
+		return 1
+	}
+
+	item, err := c.Get(ctx, ids[0])
+	if err != nil || item.ID == 0 {
+		return 1
+	}
+
+	return parallel
 }
 
 func printDeletedItem(w io.Writer, id int) error {
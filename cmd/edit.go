@@ -0,0 +1,261 @@
+/*
+Copyright © 2022 mycok <github.com/mycok>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/mycok/todo_list_client/client"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var ErrEmptyTask = errors.New("empty task")
+
+// editorScissors marks the end of the text edited in the editor. The lines
+// below it are help and are ignored. Lines starting with # above it are kept,
+// as they are Markdown headings in the notes.
+const editorScissors = "# ------------------------ >8 ------------------------"
+
+// editorHelp is appended to the file opened in the editor.
+const editorHelp = "\n" + editorScissors + `
+# Edit the task on the first line and the notes below it. Everything from
+# the line above is ignored, and an empty task aborts the edit.
+`
+
+// editCmd represents the edit command
+var editCmd = &cobra.Command{
+	Use:   "edit <itemID> [newText]",
+	Short: "Change the text or notes of a todo item",
+	Long: `Change the text of a todo item, keeping its creation date and status.
+
+The item is given by its ID, or by its position in the list with #N. Without
+a new text or any other change, the current text and notes are opened in
+$VISUAL or $EDITOR (vi by default) and the item is only updated if they were
+changed.
+
+Use --due to change the due date, given like for the add command.`,
+	Args:         cobra.MinimumNArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		rootURL := viper.GetString("api-root")
+
+		due, err := dueFromFlags(cmd)
+		if err != nil {
+			return err
+		}
+
+		return editAction(
+			cmd.Context(), os.Stdout, rootURL, args[0], args[1:], due, openEditor,
+		)
+	},
+}
+
+func editAction(
+	ctx context.Context, w io.Writer, url, id string, args []string,
+	due time.Time, edit func(text string) (string, error),
+) error {
+	refs, err := parseIDs([]string{id})
+	if err != nil {
+		return err
+	}
+
+	if len(refs) != 1 {
+		return fmt.Errorf("%w: edit takes a single item", ErrInvalidID)
+	}
+
+	c, err := newClient(url)
+	if err != nil {
+		return err
+	}
+
+	resumeOutbox(ctx, c)
+
+	ids, err := resolveIDs(ctx, c, refs)
+	if err != nil {
+		return err
+	}
+
+	var u client.ItemUpdate
+
+	switch task := strings.Join(args, " "); {
+	case len(args) > 0:
+		u.Task = &task
+
+	// Only the text is edited in the editor, and only when there is no
+	// other change to make.
+	case due.IsZero():
+		item, err := c.Get(ctx, ids[0])
+		if err != nil {
+			return err
+		}
+
+		text, err := edit(editorText(item))
+		if err != nil {
+			return err
+		}
+
+		task, notes := parseEditedText(text)
+
+		if task == item.Task && notes == item.Notes {
+			return printUnchangedItem(w, ids[0])
+		}
+
+		if task != item.Task {
+			u.Task = &task
+		}
+
+		if notes != item.Notes {
+			u.Notes = &notes
+		}
+	}
+
+	if u.Task != nil && strings.TrimSpace(*u.Task) == "" {
+		return fmt.Errorf("%w: the task text cannot be empty", ErrEmptyTask)
+	}
+
+	if !due.IsZero() {
+		u.Due = &due
+	}
+
+	if err := c.Update(ctx, ids[0], u); err != nil {
+		return err
+	}
+
+	if u.Task != nil {
+		if err := printEditedItem(w, ids[0], *u.Task); err != nil {
+			return err
+		}
+	}
+
+	if u.Notes != nil {
+		if _, err := fmt.Fprintf(w, "Item number %d notes updated\n", ids[0]); err != nil {
+			return err
+		}
+	}
+
+	if u.Due != nil {
+		return printDue(w, due)
+	}
+
+	return nil
+}
+
+// editorText returns the text of item to edit in the editor: the task on
+// the first line and the notes after a blank line.
+func editorText(item client.Item) string {
+	text := item.Task + "\n"
+
+	if item.Notes != "" {
+		text += "\n" + item.Notes + "\n"
+	}
+
+	return text + editorHelp
+}
+
+// parseEditedText splits text edited in the editor into the task, on the
+// first line that is not blank, and the notes below it.
+func parseEditedText(text string) (string, string) {
+	if i := strings.Index(text, editorScissors); i >= 0 {
+		text = text[:i]
+	}
+
+	text = strings.TrimSpace(text)
+
+	task, notes, _ := strings.Cut(text, "\n")
+
+	return strings.TrimSpace(task), strings.TrimSpace(notes)
+}
+
+// openEditor lets the user edit text in their editor, through a temporary
+// file, and returns the result.
+func openEditor(text string) (string, error) {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+
+	if editor == "" {
+		editor = "vi"
+	}
+
+	f, err := os.CreateTemp("", "todo-*.txt")
+	if err != nil {
+		return "", err
+	}
+
+	defer os.Remove(f.Name())
+
+	if _, err := f.WriteString(text); err != nil {
+		f.Close()
+
+		return "", err
+	}
+
+	if err := f.Close(); err != nil {
+		return "", err
+	}
+
+	// The editor may come with arguments, e.g. "code --wait".
+	fields := strings.Fields(editor)
+
+	cmd := exec.Command(fields[0], append(fields[1:], f.Name())...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("running editor %q: %w", editor, err)
+	}
+
+	data, err := os.ReadFile(f.Name())
+	if err != nil {
+		return "", err
+	}
+
+	return string(data), nil
+}
+
+func printEditedItem(w io.Writer, id int, task string) error {
+	_, err := fmt.Fprintf(w, "Item number %d changed to: %s\n", id, task)
+
+	return err
+}
+
+func printUnchangedItem(w io.Writer, id int) error {
+	_, err := fmt.Fprintf(w, "Item number %d unchanged\n", id)
+
+	return err
+}
+
+func init() {
+	rootCmd.AddCommand(editCmd)
+
+	editCmd.Flags().String("due", "", "New due date, e.g. 2024-03-01, tomorrow 17:00 or next friday")
+}
@@ -0,0 +1,212 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mycok/todo_list_client/client"
+)
+
+var ErrInvalidID = errors.New("invalid item ID")
+
+// maxIDs is the largest number of items a command can be given, so that a
+// mistyped range does not send millions of requests.
+const maxIDs = 10000
+
+// itemRef is an item as given on the command line: either the ID the server
+// assigned to it, or its position in the list when written as #N.
+type itemRef struct {
+	n          int
+	positional bool
+}
+
+// parseIDs expands the items given on the command line. Each argument can
+// hold several items separated by commas, and inclusive ranges such as 7-12
+// or #2-#5. Duplicates are dropped and the order of first appearance is kept.
+func parseIDs(args []string) ([]itemRef, error) {
+	var refs []itemRef
+
+	seen := make(map[itemRef]bool)
+
+	for _, arg := range args {
+		for _, spec := range strings.Split(arg, ",") {
+			spec = strings.TrimSpace(spec)
+			if spec == "" {
+				continue
+			}
+
+			first, last, positional, err := parseIDSpec(spec)
+			if err != nil {
+				return nil, err
+			}
+
+			for n := first; n <= last; n++ {
+				ref := itemRef{n: n, positional: positional}
+
+				if !seen[ref] {
+					seen[ref] = true
+					refs = append(refs, ref)
+				}
+			}
+
+			if len(refs) > maxIDs {
+				return nil, fmt.Errorf(
+					"%w: more than %d items given", ErrInvalidID, maxIDs,
+				)
+			}
+		}
+	}
+
+	if len(refs) == 0 {
+		return nil, fmt.Errorf("%w: no item IDs given", ErrInvalidID)
+	}
+
+	return refs, nil
+}
+
+// parseIDSpec parses a single item or an inclusive range of items. A range
+// starting with # is a range of positions, with or without a # on its end.
+func parseIDSpec(spec string) (int, int, bool, error) {
+	from, to, isRange := strings.Cut(spec, "-")
+
+	from = strings.TrimSpace(from)
+	positional := strings.HasPrefix(from, "#")
+
+	first, err := strconv.Atoi(strings.TrimPrefix(from, "#"))
+	if err != nil {
+		return 0, 0, false, fmt.Errorf("%w: item ID must me a number", ErrNotNumber)
+	}
+
+	last := first
+
+	if isRange {
+		to = strings.TrimSpace(to)
+
+		if strings.HasPrefix(to, "#") && !positional {
+			return 0, 0, false, fmt.Errorf("%w: %q mixes IDs and positions", ErrInvalidID, spec)
+		}
+
+		last, err = strconv.Atoi(strings.TrimPrefix(to, "#"))
+		if err != nil {
+			return 0, 0, false, fmt.Errorf("%w: item ID must me a number", ErrNotNumber)
+		}
+	}
+
+	if first < 1 || last < first {
+		return 0, 0, false, fmt.Errorf("%w: %q", ErrInvalidID, spec)
+	}
+
+	if last-first >= maxIDs {
+		return 0, 0, false, fmt.Errorf(
+			"%w: %q spans more than %d items", ErrInvalidID, spec, maxIDs,
+		)
+	}
+
+	return first, last, positional, nil
+}
+
+// resolveIDs returns the IDs to send to the API for refs. Positions are
+// looked up in a single listing of the items, taken before any change is
+// made, or in the cached list when the API is unreachable. Servers that do
+// not send IDs identify items by their position, so plain numbers and
+// positions then refer to the same items.
+func resolveIDs(
+	ctx context.Context, c *client.Client, refs []itemRef,
+) ([]int, error) {
+	var items []client.Item
+
+	if hasPositions(refs) {
+		var err error
+
+		if items, err = listAll(ctx, c); err != nil {
+			return nil, err
+		}
+	}
+
+	return resolveRefs(items, refs)
+}
+
+// hasPositions reports whether any of refs is a position.
+func hasPositions(refs []itemRef) bool {
+	for _, ref := range refs {
+		if ref.positional {
+			return true
+		}
+	}
+
+	return false
+}
+
+// resolveRefs is like resolveIDs, but looks positions up in items, the
+// full list of items.
+func resolveRefs(items []client.Item, refs []itemRef) ([]int, error) {
+	ids := make([]int, 0, len(refs))
+	seen := make(map[int]bool)
+
+	for _, ref := range refs {
+		id := ref.n
+
+		if ref.positional {
+			if ref.n > len(items) {
+				return nil, fmt.Errorf(
+					"%w: no item at position #%d, the list has %d items",
+					ErrInvalidID, ref.n, len(items),
+				)
+			}
+
+			id = items[ref.n-1].Ref()
+		}
+
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+
+	return ids, nil
+}
+
+// descending sorts ids from the highest to the lowest, so that deleting
+// them one by one on a server without IDs does not shift the position of
+// the items still to go.
+func descending(ids []int) []int {
+	sorted := append([]int(nil), ids...)
+	sort.Sort(sort.Reverse(sort.IntSlice(sorted)))
+
+	return sorted
+}
+
+// printResults writes a line for every result, using printOK for the items
+// that succeeded, and returns err, the error of the batch as a whole.
+func printResults(
+	w io.Writer, results []client.Result, err error, action string,
+	printOK func(w io.Writer, id int) error,
+) error {
+	// A single item reports its failure as is, like the other commands.
+	if len(results) == 1 && results[0].Err != nil {
+		return results[0].Err
+	}
+
+	for _, r := range results {
+		if r.Err != nil {
+			if _, err := fmt.Fprintf(
+				w, "Item number %d not %s: %s\n", r.ID, action, r.Err,
+			); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		if err := printOK(w, r.ID); err != nil {
+			return err
+		}
+	}
+
+	return err
+}
@@ -0,0 +1,114 @@
+//go:build !integration
+// +build !integration
+
+package cmd
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParseIDs(t *testing.T) {
+	testCases := []struct {
+		name        string
+		args        []string
+		expectedIDs []itemRef
+		expectedErr error
+	}{
+		{name: "Single", args: []string{"3"}, expectedIDs: idRefs(3)},
+		{name: "Separate", args: []string{"3", "5"}, expectedIDs: idRefs(3, 5)},
+		{name: "Commas", args: []string{"1,4,9"}, expectedIDs: idRefs(1, 4, 9)},
+		{
+			name:        "Range",
+			args:        []string{"3", "5", "7-10"},
+			expectedIDs: idRefs(3, 5, 7, 8, 9, 10),
+		},
+		{
+			name:        "Duplicates",
+			args:        []string{"2-4,3", "2"},
+			expectedIDs: idRefs(2, 3, 4),
+		},
+		{name: "Position", args: []string{"#3"}, expectedIDs: positionRefs(3)},
+		{
+			name:        "PositionRange",
+			args:        []string{"#2-#4", "#3-5"},
+			expectedIDs: positionRefs(2, 3, 4, 5),
+		},
+		{
+			name:        "IDsAndPositions",
+			args:        []string{"3,#3"},
+			expectedIDs: append(idRefs(3), positionRefs(3)...),
+		},
+		{name: "MixedRange", args: []string{"2-#4"}, expectedErr: ErrInvalidID},
+		{name: "NotNumber", args: []string{"me"}, expectedErr: ErrNotNumber},
+		{name: "BadRangeEnd", args: []string{"1-x"}, expectedErr: ErrNotNumber},
+		{name: "ReversedRange", args: []string{"5-2"}, expectedErr: ErrInvalidID},
+		{name: "Zero", args: []string{"0"}, expectedErr: ErrInvalidID},
+		{name: "ZeroPosition", args: []string{"#0"}, expectedErr: ErrInvalidID},
+		{name: "Empty", args: []string{","}, expectedErr: ErrInvalidID},
+		{name: "LargestRange", args: []string{"1-10000"}, expectedIDs: idRange(1, 10000)},
+		{name: "RangeTooLarge", args: []string{"1-999999999"}, expectedErr: ErrInvalidID},
+		{name: "TooManyItems", args: []string{"1-6000", "#1-#6000"}, expectedErr: ErrInvalidID},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			refs, err := parseIDs(tc.args)
+
+			if tc.expectedErr != nil {
+				if !errors.Is(err, tc.expectedErr) {
+					t.Errorf(
+						"Expected error: %q, but got: %q instead",
+						tc.expectedErr,
+						err,
+					)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Expected no error, but got: %q instead", err)
+			}
+
+			if !reflect.DeepEqual(tc.expectedIDs, refs) {
+				t.Errorf(
+					"Expected IDs: %v, but got: %v instead",
+					tc.expectedIDs,
+					refs,
+				)
+			}
+		})
+	}
+}
+
+func idRefs(ns ...int) []itemRef {
+	refs := make([]itemRef, len(ns))
+
+	for i, n := range ns {
+		refs[i] = itemRef{n: n}
+	}
+
+	return refs
+}
+
+func idRange(first, last int) []itemRef {
+	ns := make([]int, 0, last-first+1)
+
+	for n := first; n <= last; n++ {
+		ns = append(ns, n)
+	}
+
+	return idRefs(ns...)
+}
+
+func positionRefs(ns ...int) []itemRef {
+	refs := idRefs(ns...)
+
+	for i := range refs {
+		refs[i].positional = true
+	}
+
+	return refs
+}
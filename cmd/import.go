@@ -0,0 +1,280 @@
+/*
+Copyright © 2022 mycok <github.com/mycok>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// importCmd represents the import command
+var importCmd = &cobra.Command{
+	Use:   "import <file>",
+	Short: "Add the items of a todo.txt, Markdown, CSV or JSON file",
+	Long: `Add the items of a todo.txt, Markdown, CSV or JSON file, or of the
+standard input when the file is -.
+
+The format is taken from the file extension, or guessed from the content,
+and can be given with --format:
+
+  todotxt   one item per line, e.g. "(A) call mum +family @phone due:2024-03-08";
+            priorities A and B are high and medium, the others low, projects
+            and contexts become tags, and rec:1w sets a recurrence
+  markdown  the "- [ ]" and "- [x]" items of the checklists in the document;
+            tags and the priority can be given in the task as with add
+  csv, tsv  a header row naming the columns, such as task (or title), done,
+            due, priority, tags, notes and recurrence; use --map to read a
+            field from another column, e.g. --map task=Summary
+  json      the output of list -o json
+
+Completed items are added, then marked as done, unless --skip-done is given.
+The tasks already in the list or earlier in the file are skipped, ignoring
+case. Use --keep-duplicates to add them anyway.
+
+Every line is reported with its number, including the ones that could not
+be read, which do not prevent the others from being added. Use --dry-run to
+check a file without adding anything.`,
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		rootURL := viper.GetString("api-root")
+
+		opts, err := importOptionsFromFlags(cmd)
+		if err != nil {
+			return err
+		}
+
+		var data []byte
+
+		if args[0] == "-" {
+			data, err = io.ReadAll(os.Stdin)
+		} else {
+			data, err = os.ReadFile(args[0])
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if opts.format == "" {
+			opts.format = detectFormat(args[0], data)
+		}
+
+		return importAction(cmd.Context(), os.Stdout, rootURL, data, opts)
+	},
+}
+
+// importOptions holds the flags of the import command.
+type importOptions struct {
+	format         string
+	dryRun         bool
+	keepDuplicates bool
+	skipDone       bool
+
+	// columns maps item fields to the CSV columns they are read from.
+	columns map[string]string
+}
+
+func importOptionsFromFlags(cmd *cobra.Command) (importOptions, error) {
+	var opts importOptions
+
+	opts.format, _ = cmd.Flags().GetString("format")
+	opts.dryRun, _ = cmd.Flags().GetBool("dry-run")
+	opts.keepDuplicates, _ = cmd.Flags().GetBool("keep-duplicates")
+	opts.skipDone, _ = cmd.Flags().GetBool("skip-done")
+
+	mappings, _ := cmd.Flags().GetStringArray("map")
+
+	for _, m := range mappings {
+		field, column, ok := strings.Cut(m, "=")
+		if !ok || field == "" || column == "" {
+			return opts, fmt.Errorf(
+				"%w: --map %q, must be <field>=<column>", ErrInvalidImport, m,
+			)
+		}
+
+		if opts.columns == nil {
+			opts.columns = make(map[string]string)
+		}
+
+		opts.columns[strings.ToLower(field)] = column
+	}
+
+	return opts, nil
+}
+
+func importAction(
+	ctx context.Context, w io.Writer, url string, data []byte, opts importOptions,
+) error {
+	items, err := parseImport(opts.format, data, opts.columns)
+	if err != nil {
+		return err
+	}
+
+	c, err := newClient(url)
+	if err != nil {
+		return err
+	}
+
+	// A dry run changes nothing: the outbox is left for the next command
+	// to send, and the cached list as it is.
+	list := peekAll
+
+	if !opts.dryRun {
+		resumeOutbox(ctx, c)
+
+		list = listAll
+	}
+
+	// inList and inFile map the tasks already in the list to their item,
+	// and the ones read from the file to their line.
+	inList := make(map[string]int)
+	inFile := make(map[string]int)
+
+	if !opts.keepDuplicates {
+		existing, err := list(ctx, c)
+		if err != nil {
+			return err
+		}
+
+		for _, i := range existing {
+			inList[taskKey(i.Task)] = i.Ref()
+		}
+	}
+
+	var added, queued, skipped, failed int
+
+	for _, imported := range items {
+		task := imported.item.Task
+		key := taskKey(task)
+
+		ref, dupInList := inList[key]
+		line, dupInFile := inFile[key]
+
+		switch {
+		case imported.err != nil:
+			failed++
+
+			fmt.Fprintf(w, "Line %d: %s\n", imported.line, imported.err)
+
+			continue
+		case imported.done && opts.skipDone:
+			skipped++
+
+			fmt.Fprintf(w, "Line %d: skipped %q, already done\n", imported.line, task)
+
+			continue
+		case dupInList:
+			skipped++
+
+			fmt.Fprintf(w, "Line %d: skipped %q, duplicate of item number %d\n", imported.line, task, ref)
+
+			continue
+		case dupInFile && !opts.keepDuplicates:
+			skipped++
+
+			fmt.Fprintf(w, "Line %d: skipped %q, duplicate of line %d\n", imported.line, task, line)
+
+			continue
+		}
+
+		inFile[key] = imported.line
+
+		done := ""
+
+		if imported.done {
+			done = " as done"
+		}
+
+		if opts.dryRun {
+			added++
+
+			fmt.Fprintf(w, "Line %d: would add %q%s\n", imported.line, task, done)
+
+			continue
+		}
+
+		item := imported.item
+
+		op, err := newOperation(opAdd, 0, &item)
+		if err != nil {
+			return err
+		}
+
+		op.Done = imported.done
+
+		wasQueued, err := sendOrQueue(ctx, c, op)
+
+		switch {
+		case err != nil:
+			failed++
+
+			fmt.Fprintf(w, "Line %d: %q not imported: %s\n", imported.line, task, err)
+		case wasQueued:
+			queued++
+
+			fmt.Fprintf(w, "Line %d: queued %q%s\n", imported.line, task, done)
+		default:
+			added++
+
+			fmt.Fprintf(w, "Line %d: added %q%s\n", imported.line, task, done)
+		}
+	}
+
+	if opts.dryRun {
+		fmt.Fprintf(w, "Dry run: %d to add, %d skipped, %d errors\n", added, skipped, failed)
+	} else {
+		fmt.Fprintf(w, "%d added, %d queued, %d skipped, %d errors\n", added, queued, skipped, failed)
+	}
+
+	if queued > 0 {
+		fmt.Fprintln(w, "API unreachable, run sync to send the queued operations")
+	}
+
+	if failed > 0 {
+		return fmt.Errorf(
+			"%w: %d of %d items could not be imported", ErrInvalidImport, failed, len(items),
+		)
+	}
+
+	if len(items) == 0 {
+		return fmt.Errorf("%w: no items found in the %s file", ErrInvalidImport, opts.format)
+	}
+
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(importCmd)
+
+	importCmd.Flags().String("format", "", "Format of the file: todotxt, markdown, csv, tsv or json (default: guessed)")
+	importCmd.Flags().Bool("dry-run", false, "Report what would be added without adding anything")
+	importCmd.Flags().Bool("keep-duplicates", false, "Add the tasks already in the list or earlier in the file")
+	importCmd.Flags().Bool("skip-done", false, "Skip the completed items instead of adding them as done")
+	importCmd.Flags().StringArray("map", nil, "Read a field from a CSV column, as <field>=<column>, can be repeated")
+}
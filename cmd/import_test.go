@@ -0,0 +1,399 @@
+//go:build !integration
+// +build !integration
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/mycok/todo_list_client/client"
+)
+
+func TestDetectFormat(t *testing.T) {
+	testCases := []struct {
+		name     string
+		file     string
+		data     string
+		expected string
+	}{
+		{name: "TodoTxtExtension", file: "todo.txt", data: "- [ ] looks like markdown", expected: formatTodoTxt},
+		{name: "MarkdownExtension", file: "TODO.md", expected: formatMarkdown},
+		{name: "CSVExtension", file: "export.CSV", expected: formatCSV},
+		{name: "JSONContent", file: "-", data: " [{\"task\": \"a\"}]", expected: formatJSON},
+		{name: "MarkdownContent", file: "-", data: "# Groceries\n\n- [ ] milk\n", expected: formatMarkdown},
+		{name: "CSVContent", file: "-", data: "Title,Due Date\nmilk,tomorrow\n", expected: formatCSV},
+		{name: "TSVContent", file: "-", data: "task\tdone\nmilk\tfalse\n", expected: formatTSV},
+		{name: "TodoTxtContent", file: "-", data: "(A) call mum, then dad\n", expected: formatTodoTxt},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := detectFormat(tc.file, []byte(tc.data)); got != tc.expected {
+				t.Errorf("Expected format: %q, but got: %q instead", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestParseImport(t *testing.T) {
+	// summary describes an imported item in a comparable form.
+	type summary struct {
+		Line       int
+		Task       string
+		Done       bool
+		Priority   string
+		Tags       []string
+		Notes      string
+		Due        bool
+		Recurrence string
+		Err        error
+	}
+
+	testCases := []struct {
+		name        string
+		format      string
+		data        string
+		columns     map[string]string
+		expected    []summary
+		expectedErr error
+	}{
+		{
+			name:   "TodoTxt",
+			format: formatTodoTxt,
+			data: "(A) 2024-03-01 call mum +family @phone due:2024-03-08\n" +
+				"\n" +
+				"x 2024-03-02 2024-03-01 write report\n" +
+				"(C) water plants rec:+1w\n" +
+				"pay rent due:someday\n" +
+				"+home @errands\n",
+			expected: []summary{
+				{Line: 1, Task: "call mum", Priority: "high", Tags: []string{"family", "phone"}, Due: true},
+				{Line: 3, Task: "write report", Done: true},
+				{Line: 4, Task: "water plants", Priority: "low", Recurrence: "FREQ=WEEKLY"},
+				{Line: 5, Task: "pay rent", Err: ErrInvalidDue},
+				{Line: 6, Tags: []string{"home", "errands"}, Err: ErrEmptyTask},
+			},
+		},
+		{
+			name:   "Markdown",
+			format: formatMarkdown,
+			data: "# Groceries\n" +
+				"\n" +
+				"- [ ] milk +dairy\n" +
+				"  * [x] bread\n" +
+				"1. [ ] eggs !high\n" +
+				"- [ ]\n" +
+				"- not a checklist item\n",
+			expected: []summary{
+				{Line: 3, Task: "milk", Tags: []string{"dairy"}},
+				{Line: 4, Task: "bread", Done: true},
+				{Line: 5, Task: "eggs", Priority: "high"},
+				{Line: 6, Err: ErrEmptyTask},
+			},
+		},
+		{
+			name:   "CSV",
+			format: formatCSV,
+			data: "Title,Status,Due Date,Priority,Labels,Description\n" +
+				"milk,pending,2024-03-08,High,\"dairy, shop\",\"semi-skimmed\"\n" +
+				"bread,done,,,,\n" +
+				"eggs,maybe,,,,\n" +
+				"\"unterminated,,,,,\n",
+			expected: []summary{
+				{Line: 2, Task: "milk", Priority: "high", Tags: []string{"dairy", "shop"}, Notes: "semi-skimmed", Due: true},
+				{Line: 3, Task: "bread", Done: true},
+				{Line: 4, Task: "eggs", Err: ErrInvalidImport},
+				{Line: 5, Err: ErrInvalidImport},
+			},
+		},
+		{
+			name:    "CSVMapped",
+			format:  formatCSV,
+			data:    "Summary,Title\nmilk,Groceries\n",
+			columns: map[string]string{"task": "summary", "notes": "title"},
+			expected: []summary{
+				{Line: 2, Task: "milk", Notes: "Groceries"},
+			},
+		},
+		{
+			name:        "CSVWithoutTask",
+			format:      formatCSV,
+			data:        "Summary,Due\nmilk,today\n",
+			expectedErr: ErrInvalidImport,
+		},
+		{
+			name:        "CSVUnknownField",
+			format:      formatCSV,
+			data:        "task\nmilk\n",
+			columns:     map[string]string{"owner": "task"},
+			expectedErr: ErrInvalidImport,
+		},
+		{
+			name:   "TSV",
+			format: formatTSV,
+			data:   "id\ttask\tdone\ttags\n1\tmilk\tfalse\tdairy,shop\n",
+			expected: []summary{
+				{Line: 2, Task: "milk", Tags: []string{"dairy", "shop"}},
+			},
+		},
+		{
+			name:   "JSON",
+			format: formatJSON,
+			data: "[\n" +
+				"  {\n" +
+				"    \"id\": 1,\n" +
+				"    \"task\": \"milk\",\n" +
+				"    \"done\": false,\n" +
+				"    \"due\": \"2024-03-08T23:59:59Z\",\n" +
+				"    \"tags\": [\"dairy\"],\n" +
+				"    \"recurrence\": \"FREQ=WEEKLY\"\n" +
+				"  },\n" +
+				"  {\n" +
+				"    \"id\": 2,\n" +
+				"    \"task\": \"bread\",\n" +
+				"    \"done\": true\n" +
+				"  },\n" +
+				"  {\"task\": \"eggs\", \"priority\": \"urgent\"}\n" +
+				"]\n",
+			expected: []summary{
+				{Line: 2, Task: "milk", Tags: []string{"dairy"}, Due: true, Recurrence: "FREQ=WEEKLY"},
+				{Line: 10, Task: "bread", Done: true},
+				{Line: 15, Task: "eggs", Err: ErrInvalidPriority},
+			},
+		},
+		{
+			name:     "JSONItem",
+			format:   formatJSON,
+			data:     "{\"task\": \"milk\"}",
+			expected: []summary{{Line: 1, Task: "milk"}},
+		},
+		{
+			name:   "JSONInvalidItem",
+			format: formatJSON,
+			data:   "[\n{\"task\": 3},\n{\"task\": \"milk\", \"due\": \"friday\"},\n{\"task\": \"bread\"}\n]",
+			expected: []summary{
+				{Line: 2, Err: ErrInvalidImport},
+				{Line: 3, Err: ErrInvalidImport},
+				{Line: 4, Task: "bread"},
+			},
+		},
+		{
+			name:        "JSONMalformed",
+			format:      formatJSON,
+			data:        "[{\"task\": \"milk\"",
+			expectedErr: ErrInvalidImport,
+		},
+		{
+			name:        "UnknownFormat",
+			format:      "xml",
+			expectedErr: ErrInvalidImport,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			items, err := parseImport(tc.format, []byte(tc.data), tc.columns)
+
+			if tc.expectedErr != nil {
+				if !errors.Is(err, tc.expectedErr) {
+					t.Errorf("Expected error: %q, but got: %q instead", tc.expectedErr, err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Expected no error, but got: %q instead", err)
+			}
+
+			var got []summary
+
+			for _, i := range items {
+				s := summary{
+					Line:       i.line,
+					Task:       i.item.Task,
+					Done:       i.done,
+					Priority:   i.item.Priority,
+					Tags:       i.item.Tags,
+					Notes:      i.item.Notes,
+					Due:        i.item.Due != nil,
+					Recurrence: i.item.Recurrence,
+				}
+
+				// Only the sentinel of the error is compared.
+				for _, sentinel := range []error{ErrInvalidDue, ErrEmptyTask, ErrInvalidImport, ErrInvalidPriority} {
+					if errors.Is(i.err, sentinel) {
+						s.Err = sentinel
+					}
+				}
+
+				if i.err != nil && s.Err == nil {
+					t.Errorf("Line %d: unexpected error: %q", i.line, i.err)
+				}
+
+				got = append(got, s)
+			}
+
+			if !reflect.DeepEqual(tc.expected, got) {
+				t.Errorf("Expected items:\n%+v\nbut got:\n%+v\ninstead", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestImportAction(t *testing.T) {
+	data := []byte("- [ ] Call  MUM\n" +
+		"- [x] write report\n" +
+		"- [ ] buy milk !high\n" +
+		"- [ ] \n" +
+		"- [ ] buy milk\n")
+
+	testCases := []struct {
+		name              string
+		opts              importOptions
+		expectedAdded     []string
+		expectedCompleted []int
+		expectedOutput    string
+	}{
+		{
+			name:              "Import",
+			opts:              importOptions{format: formatMarkdown},
+			expectedAdded:     []string{"write report", "buy milk"},
+			expectedCompleted: []int{3},
+			expectedOutput: "Line 1: skipped \"Call MUM\", duplicate of item number 2\n" +
+				"Line 2: added \"write report\" as done\n" +
+				"Line 3: added \"buy milk\"\n" +
+				"Line 4: empty task: the task is empty\n" +
+				"Line 5: skipped \"buy milk\", duplicate of line 3\n" +
+				"2 added, 0 queued, 2 skipped, 1 errors\n",
+		},
+		{
+			name:          "SkipDone",
+			opts:          importOptions{format: formatMarkdown, skipDone: true},
+			expectedAdded: []string{"buy milk"},
+			expectedOutput: "Line 1: skipped \"Call MUM\", duplicate of item number 2\n" +
+				"Line 2: skipped \"write report\", already done\n" +
+				"Line 3: added \"buy milk\"\n" +
+				"Line 4: empty task: the task is empty\n" +
+				"Line 5: skipped \"buy milk\", duplicate of line 3\n" +
+				"1 added, 0 queued, 3 skipped, 1 errors\n",
+		},
+		{
+			name: "DryRun",
+			opts: importOptions{format: formatMarkdown, dryRun: true},
+			expectedOutput: "Line 1: skipped \"Call MUM\", duplicate of item number 2\n" +
+				"Line 2: would add \"write report\" as done\n" +
+				"Line 3: would add \"buy milk\"\n" +
+				"Line 4: empty task: the task is empty\n" +
+				"Line 5: skipped \"buy milk\", duplicate of line 3\n" +
+				"Dry run: 2 to add, 2 skipped, 1 errors\n",
+		},
+		{
+			name:              "KeepDuplicates",
+			opts:              importOptions{format: formatMarkdown, keepDuplicates: true},
+			expectedAdded:     []string{"Call MUM", "write report", "buy milk", "buy milk"},
+			expectedCompleted: []int{4},
+			expectedOutput: "Line 1: added \"Call MUM\"\n" +
+				"Line 2: added \"write report\" as done\n" +
+				"Line 3: added \"buy milk\"\n" +
+				"Line 4: empty task: the task is empty\n" +
+				"Line 5: added \"buy milk\"\n" +
+				"4 added, 0 queued, 0 skipped, 1 errors\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var (
+				added     []string
+				completed []int
+			)
+
+			items := []client.Item{{ID: 2, Task: "call mum"}}
+
+			url, cleanup := mockServer(func(w http.ResponseWriter, r *http.Request) {
+				switch r.Method {
+				case http.MethodPost:
+					var item client.NewItem
+
+					if err := json.NewDecoder(r.Body).Decode(&item); err != nil {
+						t.Error(err)
+					}
+
+					added = append(added, item.Task)
+					items = append(items, client.Item{ID: len(items) + 2, Task: item.Task})
+					w.WriteHeader(testResp["created"].Status)
+				case http.MethodPatch:
+					id, _ := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/todo/"))
+
+					completed = append(completed, id)
+					w.WriteHeader(testResp["noContent"].Status)
+				default:
+					serveItems(w, r, items)
+				}
+			})
+
+			defer cleanup()
+
+			c, err := newClient(url)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			s, err := offlineStoreFor(c)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// A dry run neither sends the queued operations nor caches
+			// the list it reads.
+			if tc.opts.dryRun {
+				op, err := newOperation(opAdd, 0, &client.NewItem{Task: "queued"})
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if err := s.queue(op); err != nil {
+					t.Fatal(err)
+				}
+
+				defer func() {
+					if ops, _ := s.pending(); len(ops) != 1 {
+						t.Errorf("Expected 1 queued operation, but got: %d instead", len(ops))
+					}
+
+					if _, cached, _ := s.loadList(); cached {
+						t.Error("Expected no cached list, but got one")
+					}
+				}()
+			}
+
+			var out bytes.Buffer
+
+			err = importAction(context.Background(), &out, url, data, tc.opts)
+			if !errors.Is(err, ErrInvalidImport) {
+				t.Errorf("Expected error: %q, but got: %q instead", ErrInvalidImport, err)
+			}
+
+			if tc.expectedOutput != out.String() {
+				t.Errorf("Expected output: %q, but got: %q instead", tc.expectedOutput, out.String())
+			}
+
+			if !reflect.DeepEqual(tc.expectedAdded, added) {
+				t.Errorf("Expected added tasks: %q, but got: %q instead", tc.expectedAdded, added)
+			}
+
+			if !reflect.DeepEqual(tc.expectedCompleted, completed) {
+				t.Errorf("Expected completed items: %v, but got: %v instead", tc.expectedCompleted, completed)
+			}
+		})
+	}
+}
@@ -0,0 +1,560 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mycok/todo_list_client/client"
+	"github.com/mycok/todo_list_client/dateparse"
+)
+
+var ErrInvalidImport = errors.New("invalid import")
+
+// The formats import reads.
+const (
+	formatTodoTxt  = "todotxt"
+	formatMarkdown = "markdown"
+	formatCSV      = "csv"
+	formatTSV      = "tsv"
+	formatJSON     = "json"
+)
+
+// importFormats maps the extensions of the files import reads to their
+// format.
+var importFormats = map[string]string{
+	".txt":      formatTodoTxt,
+	".todo":     formatTodoTxt,
+	".md":       formatMarkdown,
+	".markdown": formatMarkdown,
+	".csv":      formatCSV,
+	".tsv":      formatTSV,
+	".json":     formatJSON,
+}
+
+// importedItem is an item read from the line of an import file, or the
+// error that prevented reading it.
+type importedItem struct {
+	line int
+	item client.NewItem
+	done bool
+	err  error
+}
+
+// detectFormat returns the format of the file named name holding data,
+// from its extension or, failing that, its content.
+func detectFormat(name string, data []byte) string {
+	if format, ok := importFormats[strings.ToLower(filepath.Ext(name))]; ok {
+		return format
+	}
+
+	trimmed := bytes.TrimSpace(data)
+
+	if bytes.HasPrefix(trimmed, []byte("[")) || bytes.HasPrefix(trimmed, []byte("{")) {
+		return formatJSON
+	}
+
+	lines := strings.Split(string(trimmed), "\n")
+
+	for _, line := range lines {
+		if checklistItem.MatchString(line) {
+			return formatMarkdown
+		}
+	}
+
+	header := strings.ToLower(lines[0])
+
+	if hasTaskColumn(header, "\t") {
+		return formatTSV
+	}
+
+	if hasTaskColumn(header, ",") {
+		return formatCSV
+	}
+
+	return formatTodoTxt
+}
+
+// hasTaskColumn reports whether header, split on sep, names a task column
+// among others.
+func hasTaskColumn(header, sep string) bool {
+	names := strings.Split(header, sep)
+
+	for _, name := range names {
+		if len(names) > 1 && csvFields[normalizeColumn(name)] == "task" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// parseImport reads the items of data, a file in format. The columns of CSV
+// and TSV files are mapped to item fields by their header, with columns
+// overriding the mapping of the fields it holds.
+func parseImport(format string, data []byte, columns map[string]string) ([]importedItem, error) {
+	switch format {
+	case formatTodoTxt:
+		return parseTodoTxt(data), nil
+	case formatMarkdown:
+		return parseMarkdown(data), nil
+	case formatCSV:
+		return parseCSV(data, ',', columns)
+	case formatTSV:
+		return parseCSV(data, '\t', columns)
+	case formatJSON:
+		return parseJSON(data)
+	}
+
+	return nil, fmt.Errorf(
+		"%w: unknown format %q, must be one of: todotxt, markdown, csv, tsv, json",
+		ErrInvalidImport, format,
+	)
+}
+
+// todoTxtPriority maps the priorities of todo.txt, the letters A to Z, to
+// ours.
+func todoTxtPriority(letter byte) string {
+	switch letter {
+	case 'A':
+		return "high"
+	case 'B':
+		return "medium"
+	}
+
+	return "low"
+}
+
+var (
+	todoTxtDate         = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}$`)
+	todoTxtPriorityMark = regexp.MustCompile(`^\([A-Z]\)$`)
+	todoTxtRecur        = regexp.MustCompile(`^\+?(\d+)([dwmy])$`)
+)
+
+// parseTodoTxt reads the items of a todo.txt file, one per line, e.g.
+// "(A) 2024-03-01 call mum +family @phone due:2024-03-08". Projects and
+// contexts become tags, and the due: and rec: extensions are understood.
+func parseTodoTxt(data []byte) []importedItem {
+	var items []importedItem
+
+	for n, line := range strings.Split(string(data), "\n") {
+		words := strings.Fields(line)
+		if len(words) == 0 {
+			continue
+		}
+
+		imported := importedItem{line: n + 1}
+
+		if words[0] == "x" {
+			imported.done = true
+			words = words[1:]
+
+			// The completion date, then the creation date.
+			for i := 0; i < 2 && len(words) > 0 && todoTxtDate.MatchString(words[0]); i++ {
+				words = words[1:]
+			}
+		}
+
+		if len(words) > 0 && todoTxtPriorityMark.MatchString(words[0]) {
+			imported.item.Priority = todoTxtPriority(words[0][1])
+			words = words[1:]
+		}
+
+		if len(words) > 0 && todoTxtDate.MatchString(words[0]) {
+			words = words[1:]
+		}
+
+		var text []string
+
+		for _, word := range words {
+			key, value, _ := strings.Cut(word, ":")
+
+			switch {
+			case len(word) > 1 && (word[0] == '+' || word[0] == '@'):
+				imported.item.Tags = mergeTags(imported.item.Tags, []string{word[1:]})
+			case key == "due" && value != "":
+				due, err := dateparse.ParseDeadline(value, time.Now())
+				if err != nil {
+					imported.err = fmt.Errorf("%w: %s", ErrInvalidDue, err)
+
+					continue
+				}
+
+				imported.item.Due = &due
+			case key == "rec" && value != "":
+				m := todoTxtRecur.FindStringSubmatch(value)
+				if m == nil {
+					imported.err = fmt.Errorf("%w: %q", ErrInvalidRecurrence, value)
+
+					continue
+				}
+
+				units := map[string]string{"d": "days", "w": "weeks", "m": "months", "y": "years"}
+
+				rule, err := parseRecurrence(fmt.Sprintf("every %s %s", m[1], units[m[2]]))
+				if err != nil {
+					imported.err = err
+
+					continue
+				}
+
+				imported.item.Recurrence = rule.String()
+			default:
+				text = append(text, word)
+			}
+		}
+
+		imported.item.Task = strings.Join(text, " ")
+
+		items = append(items, checkImported(imported))
+	}
+
+	return items
+}
+
+// checklistItem matches the items of a Markdown checklist, such as
+// "- [ ] call mum" or "* [x] write report", capturing the box and the task.
+var checklistItem = regexp.MustCompile(`^\s*(?:[-*+]|\d+[.)])\s+\[([ xX])\]\s*(.*)$`)
+
+// parseMarkdown reads the items of the checklists of a Markdown document.
+// Other lines are ignored, and nested items are imported as top-level
+// ones. Tags and the priority can be given in the task as with add.
+func parseMarkdown(data []byte) []importedItem {
+	var items []importedItem
+
+	for n, line := range strings.Split(string(data), "\n") {
+		m := checklistItem.FindStringSubmatch(strings.TrimRight(line, "\r"))
+		if m == nil {
+			continue
+		}
+
+		task, tags, priority := parseTaskText(m[2])
+
+		items = append(items, checkImported(importedItem{
+			line: n + 1,
+			done: m[1] != " ",
+			item: client.NewItem{Task: task, Tags: tags, Priority: priority},
+		}))
+	}
+
+	return items
+}
+
+// csvFields maps the accepted column names, normalized, to the item field
+// they hold.
+var csvFields = map[string]string{
+	"task":        "task",
+	"title":       "task",
+	"name":        "task",
+	"item":        "task",
+	"done":        "done",
+	"completed":   "done",
+	"status":      "done",
+	"due":         "due",
+	"due_date":    "due",
+	"deadline":    "due",
+	"priority":    "priority",
+	"tags":        "tags",
+	"labels":      "tags",
+	"notes":       "notes",
+	"note":        "notes",
+	"description": "notes",
+	"recurrence":  "recurrence",
+	"repeat":      "recurrence",
+	"rrule":       "recurrence",
+}
+
+func normalizeColumn(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+
+	return strings.NewReplacer(" ", "_", "-", "_").Replace(name)
+}
+
+// parseCSV reads the items of a delimited file with a header row. The
+// columns are mapped to fields by csvFields, or by columns, which maps
+// field names to column names.
+func parseCSV(data []byte, comma rune, columns map[string]string) ([]importedItem, error) {
+	r := csv.NewReader(bytes.NewReader(data))
+	r.Comma = comma
+	r.FieldsPerRecord = -1
+
+	header, err := r.Read()
+	if err != nil {
+		return nil, fmt.Errorf("%w: cannot read the header row: %s", ErrInvalidImport, err)
+	}
+
+	index := make(map[string]int)
+
+	for i, name := range header {
+		if field, ok := csvFields[normalizeColumn(name)]; ok {
+			if _, seen := index[field]; !seen {
+				index[field] = i
+			}
+		}
+	}
+
+	for field, column := range columns {
+		if !isImportField(field) {
+			return nil, fmt.Errorf("%w: unknown field %q in --map", ErrInvalidImport, field)
+		}
+
+		found := false
+
+		for i, name := range header {
+			if strings.EqualFold(strings.TrimSpace(name), column) {
+				index[field], found = i, true
+
+				break
+			}
+		}
+
+		if !found {
+			return nil, fmt.Errorf("%w: no column %q in the header row", ErrInvalidImport, column)
+		}
+	}
+
+	if _, ok := index["task"]; !ok {
+		return nil, fmt.Errorf(
+			"%w: no task column in the header row, map one with --map task=<column>",
+			ErrInvalidImport,
+		)
+	}
+
+	var items []importedItem
+
+	for {
+		row, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		var pe *csv.ParseError
+		if errors.As(err, &pe) {
+			items = append(items, importedItem{line: pe.StartLine, err: fmt.Errorf("%w: %s", ErrInvalidImport, pe.Err)})
+
+			continue
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		line, _ := r.FieldPos(0)
+
+		value := func(field string) string {
+			if i, ok := index[field]; ok && i < len(row) {
+				return strings.TrimSpace(row[i])
+			}
+
+			return ""
+		}
+
+		imported := importedItem{line: line}
+
+		imported.err = setImportFields(&imported, importFields{
+			task:       value("task"),
+			done:       value("done"),
+			due:        value("due"),
+			priority:   value("priority"),
+			tags:       value("tags"),
+			notes:      value("notes"),
+			recurrence: value("recurrence"),
+		})
+
+		items = append(items, checkImported(imported))
+	}
+
+	return items, nil
+}
+
+// isImportField reports whether field is the name of a field the columns of
+// a CSV file can be mapped to.
+func isImportField(field string) bool {
+	for _, f := range csvFields {
+		if f == field {
+			return true
+		}
+	}
+
+	return false
+}
+
+// importFields holds the fields of an item as text, as read from a CSV row.
+type importFields struct {
+	task, done, due, priority, tags, notes, recurrence string
+}
+
+// setImportFields parses f into the item of imported.
+func setImportFields(imported *importedItem, f importFields) error {
+	item := &imported.item
+
+	item.Task = f.task
+	item.Notes = f.notes
+	item.Tags = mergeTags(nil, strings.FieldsFunc(f.tags, func(r rune) bool {
+		return r == ',' || r == ';' || r == ' ' || r == '+'
+	}))
+
+	var err error
+
+	if imported.done, err = parseDone(f.done); err != nil {
+		return err
+	}
+
+	if item.Priority, err = parsePriority(f.priority); err != nil {
+		return err
+	}
+
+	if f.due != "" {
+		due, err := dateparse.ParseDeadline(f.due, time.Now())
+		if err != nil {
+			return fmt.Errorf("%w: %s", ErrInvalidDue, err)
+		}
+
+		item.Due = &due
+	}
+
+	if f.recurrence != "" {
+		rule, err := parseRecurrence(f.recurrence)
+		if err != nil {
+			return err
+		}
+
+		item.Recurrence = rule.String()
+	}
+
+	return nil
+}
+
+// parseDone parses the done column of a CSV row, which holds a boolean or a
+// status such as done or pending.
+func parseDone(value string) (bool, error) {
+	switch strings.ToLower(value) {
+	case "", "pending", "todo", "open", "no", "n":
+		return false, nil
+	case "done", "completed", "complete", "closed", "x", "yes", "y":
+		return true, nil
+	}
+
+	done, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("%w: invalid done value %q", ErrInvalidImport, value)
+	}
+
+	return done, nil
+}
+
+// importRecord is an item of the JSON output of the list and view commands.
+type importRecord struct {
+	Task       string     `json:"task"`
+	Done       bool       `json:"done"`
+	Due        *time.Time `json:"due"`
+	Priority   string     `json:"priority"`
+	Tags       []string   `json:"tags"`
+	Notes      string     `json:"notes"`
+	Recurrence string     `json:"recurrence"`
+}
+
+// parseJSON reads the items of the JSON output of list, or the single item
+// of the output of view. An item with fields of the wrong type is reported
+// on its own, while malformed JSON stops the whole file from being read.
+func parseJSON(data []byte) ([]importedItem, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+
+	var items []importedItem
+
+	decodeItem := func() error {
+		line := lineAt(data, dec.InputOffset())
+
+		var raw json.RawMessage
+
+		if err := dec.Decode(&raw); err != nil {
+			return fmt.Errorf("%w: line %d: %s", ErrInvalidImport, line, err)
+		}
+
+		var r importRecord
+
+		if err := json.Unmarshal(raw, &r); err != nil {
+			items = append(items, importedItem{
+				line: line,
+				err:  fmt.Errorf("%w: %s", ErrInvalidImport, err),
+			})
+
+			return nil
+		}
+
+		imported := importedItem{
+			line: line,
+			done: r.Done,
+			item: client.NewItem{
+				Task:  r.Task,
+				Due:   r.Due,
+				Tags:  r.Tags,
+				Notes: r.Notes,
+			},
+		}
+
+		imported.item.Priority, imported.err = parsePriority(r.Priority)
+
+		if r.Recurrence != "" && imported.err == nil {
+			rule, err := parseRecurrence(r.Recurrence)
+
+			imported.item.Recurrence, imported.err = rule.String(), err
+		}
+
+		items = append(items, checkImported(imported))
+
+		return nil
+	}
+
+	if !bytes.HasPrefix(bytes.TrimSpace(data), []byte("[")) {
+		return items, decodeItem()
+	}
+
+	// Skip the opening bracket.
+	if _, err := dec.Token(); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidImport, err)
+	}
+
+	for dec.More() {
+		if err := decodeItem(); err != nil {
+			return nil, err
+		}
+	}
+
+	return items, nil
+}
+
+// lineAt returns the line of the first value after offset in data, skipping
+// the spaces and the comma that separate values.
+func lineAt(data []byte, offset int64) int {
+	i := int(offset)
+
+	for i < len(data) && strings.ContainsRune(" \t\r\n,", rune(data[i])) {
+		i++
+	}
+
+	return bytes.Count(data[:i], []byte("\n")) + 1
+}
+
+// checkImported returns imported with an error if it has no task.
+func checkImported(imported importedItem) importedItem {
+	if imported.err == nil && strings.TrimSpace(imported.item.Task) == "" {
+		imported.err = fmt.Errorf("%w: the task is empty", ErrEmptyTask)
+	}
+
+	return imported
+}
+
+// taskKey returns the form of task compared to find duplicates, ignoring
+// case and spacing.
+func taskKey(task string) string {
+	return strings.ToLower(strings.Join(strings.Fields(task), " "))
+}
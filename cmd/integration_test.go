@@ -6,6 +6,7 @@ package cmd
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"fmt"
 	"math/rand"
 	"os"
@@ -30,13 +31,13 @@ func TestIntegration(t *testing.T) {
 	// Integration tests to execute include
 	// [Add, List, View, Complete, ListComplete, Delete, ListDeletedTask].
 	t.Run("Add", func(t *testing.T) {
-		outputBuf := &bytes.Buffer{} 
+		outputBuf := &bytes.Buffer{}
 
 		args := []string{tName}
 
 		expectedOutput := fmt.Sprintf("Added item: %s : to the list\n", tName)
 
-		if err := addAction(outputBuf, apiRoot, args); err != nil {
+		if err := addAction(context.Background(), outputBuf, apiRoot, args, addOptions{}); err != nil {
 			t.Fatalf("Expected no error, but got: %q instead", err)
 		}
 
@@ -52,7 +53,7 @@ func TestIntegration(t *testing.T) {
 	t.Run("List", func(t *testing.T) {
 		outputBuf := &bytes.Buffer{}
 
-		if err := listAction(outputBuf, apiRoot); err != nil {
+		if err := listAction(context.Background(), outputBuf, apiRoot, textFormatter{}, listOptions{}); err != nil {
 			t.Fatalf("Expected no error, but got: %q instead", err)
 		}
 
@@ -89,7 +90,7 @@ func TestIntegration(t *testing.T) {
 	vResp := t.Run("View", func(t *testing.T) {
 		outputBuf := &bytes.Buffer{}
 
-		if err := viewAction(outputBuf, apiRoot, taskID); err != nil {
+		if err := viewAction(context.Background(), outputBuf, apiRoot, taskID, textFormatter{}); err != nil {
 			t.Fatalf("Expected no error, but got: %q instead", err)
 		}
 
@@ -131,7 +132,7 @@ func TestIntegration(t *testing.T) {
 	t.Run("Complete", func(t *testing.T) {
 		outputBuf := &bytes.Buffer{}
 
-		if err := completeAction(outputBuf, apiRoot, taskID); err != nil {
+		if err := completeAction(context.Background(), outputBuf, apiRoot, []string{taskID}, false); err != nil {
 			t.Fatalf("Expected no error, but got: %q instead", err)
 		}
 
@@ -152,7 +153,7 @@ func TestIntegration(t *testing.T) {
 	t.Run("ListAfterComplete", func(t *testing.T) {
 		outputBuf := &bytes.Buffer{}
 
-		if err := listAction(outputBuf, apiRoot); err != nil {
+		if err := listAction(context.Background(), outputBuf, apiRoot, textFormatter{}, listOptions{}); err != nil {
 			t.Fatalf("Expected no error, but got: %q instead", err)
 		}
 
@@ -186,7 +187,7 @@ func TestIntegration(t *testing.T) {
 	t.Run("Delete", func(t *testing.T) {
 		outputBuf := &bytes.Buffer{}
 
-		if err := deleteAction(outputBuf, apiRoot, taskID); err != nil {
+		if err := deleteAction(context.Background(), outputBuf, apiRoot, []string{taskID}); err != nil {
 			t.Fatalf("Expected no error, but got: %q instead", err)
 		}
 
@@ -204,7 +205,7 @@ func TestIntegration(t *testing.T) {
 	t.Run("ListAfterDelete", func(t *testing.T) {
 		outputBuf := &bytes.Buffer{}
 
-		if err := listAction(outputBuf, apiRoot); err != nil {
+		if err := listAction(context.Background(), outputBuf, apiRoot, textFormatter{}, listOptions{}); err != nil {
 			t.Fatalf("Expected no error, but got: %q instead", err)
 		}
 
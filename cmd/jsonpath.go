@@ -0,0 +1,699 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// jsonPath is a compiled JSONPath template, as kubectl accepts them: text
+// in which each {expression} is replaced by the values it selects, separated
+// by spaces. Expressions support child names, wildcards, array indexes and
+// slices, recursive descent and filters such as {[?(@.done==false)].id}.
+// {"\n"} and other quoted strings print as text, and
+// {range [*]}...{end} repeats its body for each value selected, with the
+// expressions of the body applied to that value and $ still the root.
+// A template without braces is a single expression.
+type jsonPath struct {
+	segments []pathSegment
+}
+
+type pathStep func(nodes []interface{}) []interface{}
+
+// pathSegment is a part of a template: plain text, an expression, or a range
+// over the values of an expression, with its body.
+type pathSegment struct {
+	text  string
+	expr  *pathExpr
+	body  []pathSegment
+	isEnd bool
+}
+
+// pathExpr is a compiled expression, applied to the root of the data when
+// it starts with $ and to the current value otherwise.
+type pathExpr struct {
+	root  bool
+	steps []pathStep
+}
+
+// parseJSONPath compiles the template expr.
+func parseJSONPath(expr string) (*jsonPath, error) {
+	p := &jsonPath{}
+
+	if !strings.Contains(expr, "{") {
+		e, err := parsePathExpr(expr)
+		if err != nil {
+			return nil, fmt.Errorf("jsonpath %q: %w", expr, err)
+		}
+
+		p.segments = []pathSegment{{expr: e}}
+
+		return p, nil
+	}
+
+	segments, rest, err := parseSegments(expr)
+
+	switch {
+	case err != nil:
+		return nil, fmt.Errorf("jsonpath %q: %w", expr, err)
+	case len(rest) > 0 || (len(segments) > 0 && segments[len(segments)-1].isEnd):
+		return nil, fmt.Errorf("jsonpath %q: {end} without {range}", expr)
+	}
+
+	p.segments = segments
+
+	return p, nil
+}
+
+// parseSegments reads the segments of s up to the {end} closing the range
+// they are the body of, which is the last segment returned, or to the end of
+// s. rest is what follows the {end}.
+func parseSegments(s string) ([]pathSegment, string, error) {
+	var segments []pathSegment
+
+	for len(s) > 0 {
+		open := strings.IndexByte(s, '{')
+		if open < 0 {
+			return append(segments, pathSegment{text: s}), "", nil
+		}
+
+		if open > 0 {
+			segments = append(segments, pathSegment{text: s[:open]})
+		}
+
+		end, err := closingBrace(s, open)
+		if err != nil {
+			return nil, "", err
+		}
+
+		action := strings.TrimSpace(s[open+1 : end])
+		s = s[end+1:]
+
+		switch {
+		case action == "end":
+			return append(segments, pathSegment{isEnd: true}), s, nil
+
+		case strings.HasPrefix(action, "range ") || strings.HasPrefix(action, "range\t"):
+			e, err := parsePathExpr(action[len("range"):])
+			if err != nil {
+				return nil, "", err
+			}
+
+			body, rest, err := parseSegments(s)
+			if err != nil {
+				return nil, "", err
+			}
+
+			if len(body) == 0 || !body[len(body)-1].isEnd {
+				return nil, "", fmt.Errorf("{%s} without {end}", action)
+			}
+
+			segments = append(segments, pathSegment{expr: e, body: body[:len(body)-1]})
+			s = rest
+
+		case isQuoted(action):
+			text, err := unquoteText(action)
+			if err != nil {
+				return nil, "", err
+			}
+
+			segments = append(segments, pathSegment{text: text})
+
+		default:
+			e, err := parsePathExpr(action)
+			if err != nil {
+				return nil, "", err
+			}
+
+			segments = append(segments, pathSegment{expr: e})
+		}
+	}
+
+	return segments, "", nil
+}
+
+// closingBrace returns the index of the '}' matching the '{' at start,
+// skipping over quoted strings.
+func closingBrace(s string, start int) (int, error) {
+	var quote byte
+
+	for i := start + 1; i < len(s); i++ {
+		c := s[i]
+
+		switch {
+		case quote != 0 && c == '\\':
+			i++
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '}':
+			return i, nil
+		}
+	}
+
+	return 0, fmt.Errorf("unterminated '{' at offset %d", start)
+}
+
+// unquoteText returns the text of a quoted string of a template. Double
+// quoted strings can hold escapes such as \n and \t.
+func unquoteText(s string) (string, error) {
+	if s[0] == '\'' {
+		return s[1 : len(s)-1], nil
+	}
+
+	text, err := strconv.Unquote(s)
+	if err != nil {
+		return "", fmt.Errorf("invalid string %s", s)
+	}
+
+	return text, nil
+}
+
+// parsePathExpr compiles a single expression. The leading $ or @ is
+// optional.
+func parsePathExpr(expr string) (*pathExpr, error) {
+	e := strings.TrimSpace(expr)
+
+	root := strings.HasPrefix(e, "$")
+
+	e = strings.TrimPrefix(strings.TrimPrefix(e, "$"), "@")
+
+	steps, err := parsePathSteps(e)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pathExpr{root: root, steps: steps}, nil
+}
+
+// execute writes the template applied to data, a value decoded from JSON.
+func (p *jsonPath) execute(w io.Writer, data interface{}) error {
+	return executeSegments(w, p.segments, data, data)
+}
+
+func executeSegments(w io.Writer, segments []pathSegment, root, current interface{}) error {
+	for _, seg := range segments {
+		if seg.expr == nil {
+			if _, err := io.WriteString(w, seg.text); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		values := seg.expr.eval(root, current)
+
+		if seg.body != nil {
+			for _, v := range values {
+				if err := executeSegments(w, seg.body, root, v); err != nil {
+					return err
+				}
+			}
+
+			continue
+		}
+
+		text := make([]string, len(values))
+
+		for i, v := range values {
+			s, err := formatPathValue(v)
+			if err != nil {
+				return err
+			}
+
+			text[i] = s
+		}
+
+		if _, err := io.WriteString(w, strings.Join(text, " ")); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// formatPathValue returns strings as they are, and other values as JSON.
+func formatPathValue(v interface{}) (string, error) {
+	if s, ok := v.(string); ok {
+		return s, nil
+	}
+
+	b, err := json.Marshal(v)
+
+	return string(b), err
+}
+
+// eval applies the expression to root or current, values decoded from JSON.
+func (e *pathExpr) eval(root, current interface{}) []interface{} {
+	nodes := []interface{}{current}
+
+	if e.root {
+		nodes = []interface{}{root}
+	}
+
+	for _, step := range e.steps {
+		nodes = step(nodes)
+	}
+
+	return nodes
+}
+
+func parsePathSteps(e string) ([]pathStep, error) {
+	var steps []pathStep
+
+	for i := 0; i < len(e); {
+		switch {
+		case strings.HasPrefix(e[i:], ".."):
+			i += 2
+			name, n := readPathName(e[i:])
+			if name == "" {
+				return nil, fmt.Errorf("missing name after '..' at offset %d", i)
+			}
+
+			i += n
+			steps = append(steps, recursiveStep(name))
+
+		case e[i] == '.':
+			i++
+			name, n := readPathName(e[i:])
+			i += n
+
+			switch name {
+			case "":
+				// A lone dot refers to the current node.
+			case "*":
+				steps = append(steps, wildcardStep)
+			default:
+				steps = append(steps, childStep(name))
+			}
+
+		case e[i] == '[':
+			end, err := closingBracket(e, i)
+			if err != nil {
+				return nil, err
+			}
+
+			step, err := parseBracket(e[i+1 : end])
+			if err != nil {
+				return nil, err
+			}
+
+			steps = append(steps, step)
+			i = end + 1
+
+		default:
+			name, n := readPathName(e[i:])
+			if n == 0 {
+				return nil, fmt.Errorf("unexpected %q at offset %d", e[i], i)
+			}
+
+			i += n
+
+			if name == "*" {
+				steps = append(steps, wildcardStep)
+			} else {
+				steps = append(steps, childStep(name))
+			}
+		}
+	}
+
+	return steps, nil
+}
+
+func readPathName(s string) (string, int) {
+	n := strings.IndexAny(s, ".[")
+	if n < 0 {
+		n = len(s)
+	}
+
+	return strings.TrimSpace(s[:n]), n
+}
+
+// closingBracket returns the index of the ']' matching the '[' at start,
+// skipping over quoted strings and nested brackets.
+func closingBracket(e string, start int) (int, error) {
+	depth := 0
+	var quote byte
+
+	for i := start; i < len(e); i++ {
+		c := e[i]
+
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '[':
+			depth++
+		case c == ']':
+			depth--
+			if depth == 0 {
+				return i, nil
+			}
+		}
+	}
+
+	return 0, fmt.Errorf("unterminated '[' at offset %d", start)
+}
+
+func parseBracket(s string) (pathStep, error) {
+	s = strings.TrimSpace(s)
+
+	switch {
+	case s == "*":
+		return wildcardStep, nil
+
+	case strings.HasPrefix(s, "?(") && strings.HasSuffix(s, ")"):
+		return parseFilter(s[2 : len(s)-1])
+
+	case isQuoted(s):
+		return childStep(s[1 : len(s)-1]), nil
+
+	case strings.Contains(s, ":"):
+		return parseSlice(s)
+	}
+
+	idx, err := strconv.Atoi(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid subscript [%s]", s)
+	}
+
+	return indexStep(idx), nil
+}
+
+func isQuoted(s string) bool {
+	return len(s) >= 2 &&
+		(s[0] == '\'' || s[0] == '"') && s[len(s)-1] == s[0]
+}
+
+func childStep(name string) pathStep {
+	return func(nodes []interface{}) []interface{} {
+		var out []interface{}
+
+		for _, n := range nodes {
+			if m, ok := n.(map[string]interface{}); ok {
+				if v, ok := m[name]; ok {
+					out = append(out, v)
+				}
+			}
+		}
+
+		return out
+	}
+}
+
+func wildcardStep(nodes []interface{}) []interface{} {
+	var out []interface{}
+
+	for _, n := range nodes {
+		out = append(out, children(n)...)
+	}
+
+	return out
+}
+
+func children(n interface{}) []interface{} {
+	switch v := n.(type) {
+	case []interface{}:
+		return v
+	case map[string]interface{}:
+		out := make([]interface{}, 0, len(v))
+
+		for _, key := range sortedKeys(v) {
+			out = append(out, v[key])
+		}
+
+		return out
+	}
+
+	return nil
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+
+	for key := range m {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+func recursiveStep(name string) pathStep {
+	return func(nodes []interface{}) []interface{} {
+		var out []interface{}
+
+		var walk func(n interface{})
+		walk = func(n interface{}) {
+			if m, ok := n.(map[string]interface{}); ok {
+				if name == "*" {
+					out = append(out, children(m)...)
+				} else if v, ok := m[name]; ok {
+					out = append(out, v)
+				}
+			}
+
+			for _, c := range children(n) {
+				walk(c)
+			}
+		}
+
+		for _, n := range nodes {
+			walk(n)
+		}
+
+		return out
+	}
+}
+
+func indexStep(idx int) pathStep {
+	return func(nodes []interface{}) []interface{} {
+		var out []interface{}
+
+		for _, n := range nodes {
+			a, ok := n.([]interface{})
+			if !ok {
+				continue
+			}
+
+			i := idx
+			if i < 0 {
+				i += len(a)
+			}
+
+			if i >= 0 && i < len(a) {
+				out = append(out, a[i])
+			}
+		}
+
+		return out
+	}
+}
+
+func parseSlice(s string) (pathStep, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("invalid slice [%s]", s)
+	}
+
+	bounds := make([]*int, 2)
+
+	for i, p := range parts {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+
+		v, err := strconv.Atoi(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid slice [%s]", s)
+		}
+
+		bounds[i] = &v
+	}
+
+	return func(nodes []interface{}) []interface{} {
+		var out []interface{}
+
+		for _, n := range nodes {
+			a, ok := n.([]interface{})
+			if !ok {
+				continue
+			}
+
+			start, end := 0, len(a)
+
+			if bounds[0] != nil {
+				start = clampIndex(*bounds[0], len(a))
+			}
+
+			if bounds[1] != nil {
+				end = clampIndex(*bounds[1], len(a))
+			}
+
+			if start < end {
+				out = append(out, a[start:end]...)
+			}
+		}
+
+		return out
+	}, nil
+}
+
+func clampIndex(i, length int) int {
+	if i < 0 {
+		i += length
+	}
+
+	if i < 0 {
+		return 0
+	}
+
+	if i > length {
+		return length
+	}
+
+	return i
+}
+
+var filterOperators = []string{"==", "!=", "<=", ">=", "<", ">"}
+
+// parseFilter compiles a filter such as @.done==false or @.task. Filters
+// select the children of each node that match the condition.
+func parseFilter(s string) (pathStep, error) {
+	s = strings.TrimSpace(s)
+
+	op := ""
+	left, right := s, ""
+
+	for _, o := range filterOperators {
+		if i := strings.Index(s, o); i >= 0 {
+			op = o
+			left, right = strings.TrimSpace(s[:i]), strings.TrimSpace(s[i+len(o):])
+
+			break
+		}
+	}
+
+	if !strings.HasPrefix(left, "@") {
+		return nil, fmt.Errorf("filter %q must start with @", s)
+	}
+
+	steps, err := parsePathSteps(left[1:])
+	if err != nil {
+		return nil, err
+	}
+
+	sub := &pathExpr{steps: steps}
+
+	var literal interface{}
+
+	if op != "" {
+		err := json.Unmarshal([]byte(normalizeLiteral(right)), &literal)
+		if err != nil || !isScalar(literal) {
+			return nil, fmt.Errorf("invalid filter value %q", right)
+		}
+	}
+
+	return func(nodes []interface{}) []interface{} {
+		var out []interface{}
+
+		for _, n := range nodes {
+			for _, c := range children(n) {
+				values := sub.eval(c, c)
+				if len(values) == 0 {
+					continue
+				}
+
+				if op == "" || compareJSON(values[0], op, literal) {
+					out = append(out, c)
+				}
+			}
+		}
+
+		return out
+	}, nil
+}
+
+func isScalar(v interface{}) bool {
+	switch v.(type) {
+	case nil, bool, float64, string:
+		return true
+	}
+
+	return false
+}
+
+// normalizeLiteral turns single quoted strings into JSON strings.
+func normalizeLiteral(s string) string {
+	if len(s) >= 2 && s[0] == '\'' && s[len(s)-1] == '\'' {
+		return strconv.Quote(s[1 : len(s)-1])
+	}
+
+	return s
+}
+
+func compareJSON(a interface{}, op string, b interface{}) bool {
+	switch av := a.(type) {
+	case float64:
+		bv, ok := b.(float64)
+		if !ok {
+			return op == "!="
+		}
+
+		switch op {
+		case "==":
+			return av == bv
+		case "!=":
+			return av != bv
+		case "<":
+			return av < bv
+		case "<=":
+			return av <= bv
+		case ">":
+			return av > bv
+		case ">=":
+			return av >= bv
+		}
+
+	case string:
+		bv, ok := b.(string)
+		if !ok {
+			return op == "!="
+		}
+
+		switch op {
+		case "==":
+			return av == bv
+		case "!=":
+			return av != bv
+		case "<":
+			return av < bv
+		case "<=":
+			return av <= bv
+		case ">":
+			return av > bv
+		case ">=":
+			return av >= bv
+		}
+	}
+
+	switch op {
+	case "==":
+		return a == b
+	case "!=":
+		return a != b
+	}
+
+	return false
+}
@@ -22,15 +22,25 @@ THE SOFTWARE.
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
+	"regexp"
+	"sort"
+	"strings"
 	"text/tabwriter"
+	"time"
 
+	"github.com/mycok/todo_list_client/client"
+	"github.com/mycok/todo_list_client/dateparse"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
 
+var ErrInvalidFilter = errors.New("invalid filter")
+
 // listCmd represents the list command
 var listCmd = &cobra.Command{
 	Use:          "list",
@@ -39,31 +49,386 @@ var listCmd = &cobra.Command{
 	RunE: func(cmd *cobra.Command, args []string) error {
 		rootURL := viper.GetString("api-root")
 
-		return listAction(os.Stdout, rootURL)
+		f, err := formatterFromFlags(cmd)
+		if err != nil {
+			return err
+		}
+
+		opts, err := listOptionsFromFlags(cmd)
+		if err != nil {
+			return err
+		}
+
+		if tree, _ := cmd.Flags().GetBool("tree"); tree {
+			if _, ok := f.(textFormatter); !ok {
+				return fmt.Errorf("%w: --tree only applies to the text output", ErrInvalidFormat)
+			}
+
+			f = treeFormatter{}
+		}
+
+		return listAction(cmd.Context(), os.Stdout, rootURL, f, opts)
+	},
+}
+
+// listOptions holds the filters and the ordering applied by the list
+// command.
+type listOptions struct {
+	query   client.Query
+	sortBy  string
+	reverse bool
+
+	// limit and page restrict the items fetched from servers that page
+	// their results. Without them, every page is listed.
+	limit int
+	page  int
+}
+
+// iterOptions returns the pages of the items listAction fetches.
+func (o listOptions) iterOptions() client.IterOptions {
+	return client.IterOptions{Page: o.page, Limit: o.limit}
+}
+
+// sortKeys maps the values accepted by --sort to the ordering they apply.
+var sortKeys = map[string]func(a, b record) bool{
+	"created": func(a, b record) bool {
+		return a.CreatedAt.Before(b.CreatedAt)
+	},
+	"completed": func(a, b record) bool {
+		return completedAt(a).Before(completedAt(b))
+	},
+	"task": func(a, b record) bool {
+		return strings.ToLower(a.Task) < strings.ToLower(b.Task)
 	},
+	"status": func(a, b record) bool {
+		return !a.Done && b.Done
+	},
+	"priority": func(a, b record) bool {
+		return priorityRank(a.Priority) < priorityRank(b.Priority)
+	},
+	"due": func(a, b record) bool {
+		// Items without a deadline come last.
+		return a.Due != nil && (b.Due == nil || a.Due.Before(*b.Due))
+	},
+}
+
+func completedAt(r record) time.Time {
+	if r.CompletedAt == nil {
+		return time.Time{}
+	}
+
+	return *r.CompletedAt
+}
+
+func listOptionsFromFlags(cmd *cobra.Command) (listOptions, error) {
+	var opts listOptions
+
+	flags := cmd.Flags()
+
+	pending, _ := flags.GetBool("pending")
+	done, _ := flags.GetBool("done")
+
+	switch {
+	case pending && done:
+		return opts, fmt.Errorf(
+			"%w: --pending and --done cannot be used together",
+			ErrInvalidFilter,
+		)
+	case pending || done:
+		opts.query.Done = &done
+	}
+
+	search, _ := flags.GetString("search")
+
+	if len(search) > 1 && strings.HasPrefix(search, "/") &&
+		strings.HasSuffix(search, "/") {
+		re, err := regexp.Compile(search[1 : len(search)-1])
+		if err != nil {
+			return opts, fmt.Errorf("%w: --search: %s", ErrInvalidFilter, err)
+		}
+
+		opts.query.Pattern = re
+	}
+
+	opts.query.Search = search
+
+	times := []struct {
+		flag string
+		dst  *time.Time
+	}{
+		{"created-after", &opts.query.CreatedAfter},
+		{"created-before", &opts.query.CreatedBefore},
+		{"completed-since", &opts.query.CompletedSince},
+	}
+
+	for _, t := range times {
+		value, _ := flags.GetString(t.flag)
+		if value == "" {
+			continue
+		}
+
+		parsed, err := parseTime(value)
+		if err != nil {
+			return opts, fmt.Errorf("%w: --%s: %s", ErrInvalidFilter, t.flag, err)
+		}
+
+		*t.dst = parsed
+	}
+
+	overdue, _ := flags.GetBool("overdue")
+	dueBefore, _ := flags.GetString("due-before")
+
+	switch {
+	case overdue && done:
+		return opts, fmt.Errorf(
+			"%w: --overdue and --done cannot be used together",
+			ErrInvalidFilter,
+		)
+	case overdue && dueBefore != "":
+		return opts, fmt.Errorf(
+			"%w: --overdue and --due-before cannot be used together",
+			ErrInvalidFilter,
+		)
+	case overdue:
+		pending := false
+		opts.query.Done = &pending
+		opts.query.DueBefore = time.Now()
+	case dueBefore != "":
+		parsed, err := dateparse.ParseDeadline(dueBefore, time.Now())
+		if err != nil {
+			return opts, fmt.Errorf("%w: --due-before: %s", ErrInvalidFilter, err)
+		}
+
+		opts.query.DueBefore = parsed
+	}
+
+	opts.query.Tags, _ = flags.GetStringArray("tag")
+
+	priority, _ := flags.GetString("priority")
+
+	p, err := parsePriority(priority)
+	if err != nil {
+		return opts, fmt.Errorf("%w: --priority: %s", ErrInvalidFilter, err)
+	}
+
+	opts.query.Priority = p
+
+	opts.sortBy, _ = flags.GetString("sort")
+	opts.reverse, _ = flags.GetBool("reverse")
+
+	if _, ok := sortKeys[opts.sortBy]; opts.sortBy != "" && !ok {
+		return opts, fmt.Errorf(
+			"%w: unknown sort key %q, must be one of: created, completed, task, status, due, priority",
+			ErrInvalidFilter, opts.sortBy,
+		)
+	}
+
+	opts.limit, _ = flags.GetInt("limit")
+	opts.page, _ = flags.GetInt("page")
+
+	switch {
+	case opts.limit < 0:
+		return opts, fmt.Errorf("%w: --limit must be positive", ErrInvalidFilter)
+	case opts.page < 0:
+		return opts, fmt.Errorf("%w: --page must be positive", ErrInvalidFilter)
+	}
+
+	return opts, nil
 }
 
-func listAction(w io.Writer, url string) error {
-	items, err := getAll(url)
+// parseTime parses the date given to a filter, such as 2024-03-01 or
+// "yesterday", in the local time zone unless it carries its own.
+func parseTime(value string) (time.Time, error) {
+	return dateparse.Parse(value, time.Now())
+}
 
+func listAction(
+	ctx context.Context, w io.Writer, url string, f formatter, opts listOptions,
+) error {
+	c, err := newClient(url)
 	if err != nil {
 		return err
 	}
 
-	return printItems(w, items)
+	resumeOutbox(ctx, c)
+
+	iterOpts := opts.iterOptions()
+	it := c.Iter(ctx, opts.query, iterOpts)
+
+	if !it.Next() {
+		err := it.Err()
+
+		switch {
+		case errors.Is(err, client.ErrConnection):
+			return listCached(w, c, f, opts, err)
+		case err != nil:
+			return err
+		}
+
+		return fmt.Errorf("%w: no items match the filters", client.ErrNotFound)
+	}
+
+	// Only the full list is cached for offline use.
+	full := opts.query.IsZero() && iterOpts.Page <= 1
+
+	// Unsorted lists are written as the pages come in by the formats that
+	// allow it, so that long lists are never held in memory. They are
+	// cached the same way.
+	if sf, ok := f.(streamFormatter); ok && opts.sortBy == "" && !opts.reverse {
+		var cache *listCache
+
+		if full {
+			cache = newListCache(c)
+		}
+
+		defer cache.abort()
+
+		rw := sf.stream(w)
+
+		for more := true; more; more = it.Next() {
+			cache.add(it.Item())
+
+			if err := rw.write(newRecord(it.Item().Ref(), it.Item())); err != nil {
+				return err
+			}
+		}
+
+		if err := it.Err(); err != nil {
+			return err
+		}
+
+		if err := rw.close(); err != nil {
+			return err
+		}
+
+		if !it.More() {
+			cache.commit()
+		}
+
+		return printMore(it)
+	}
+
+	var items []client.Item
+
+	for more := true; more; more = it.Next() {
+		items = append(items, it.Item())
+	}
+
+	if err := it.Err(); err != nil {
+		return err
+	}
+
+	if full && !it.More() {
+		cacheItems(c, items)
+	}
+
+	if err := writeItems(w, f, opts, items); err != nil {
+		return err
+	}
+
+	return printMore(it)
 }
 
-func printItems(w io.Writer, items []item) error {
+// listCached lists the cached items of c that match the filters, after the
+// API could not be reached with err.
+func listCached(
+	w io.Writer, c *client.Client, f formatter, opts listOptions, err error,
+) error {
+	cached, err := cachedItems(c, err)
+	if err != nil {
+		return err
+	}
+
+	var items []client.Item
+
+	for _, i := range cached {
+		if opts.limit > 0 && len(items) == opts.limit {
+			break
+		}
+
+		if opts.query.Match(i) {
+			items = append(items, i)
+		}
+	}
+
+	if len(items) == 0 {
+		return fmt.Errorf("%w: no items match the filters", client.ErrNotFound)
+	}
+
+	return writeItems(w, f, opts, items)
+}
+
+// writeItems writes items with f, in the order opts asks for.
+func writeItems(w io.Writer, f formatter, opts listOptions, items []client.Item) error {
+	records := newRecords(items)
+
+	if less, ok := sortKeys[opts.sortBy]; ok {
+		sort.SliceStable(records, func(i, j int) bool {
+			return less(records[i], records[j])
+		})
+	}
+
+	if opts.reverse {
+		for i, j := 0, len(records)-1; i < j; i, j = i+1, j-1 {
+			records[i], records[j] = records[j], records[i]
+		}
+	}
+
+	return f.formatList(w, records)
+}
+
+// printMore tells, on stderr, that the server has more items than the ones
+// --page or --limit listed.
+func printMore(it *client.Iterator) error {
+	if !it.More() {
+		return nil
+	}
+
+	_, err := fmt.Fprintln(
+		stderr, "More items are available, change or drop --page and --limit to see them",
+	)
+
+	return err
+}
+
+func printItems(w io.Writer, records []record) error {
 	tw := tabwriter.NewWriter(w, 3, 2, 0, ' ', 0)
 
-	for i, item := range items {
+	// The due column is only shown when some item has a deadline.
+	withDue := false
+
+	for _, r := range records {
+		if r.Due != nil {
+			withDue = true
+
+			break
+		}
+	}
+
+	color := useColor(w)
+	now := time.Now()
+
+	for _, r := range records {
 		done := "𝘅"
 
-		if item.Done {
+		if r.Done {
 			done = "✅"
 		}
 
-		fmt.Fprintf(tw, "%s\t%d\t%s\t\n", done, i+1, item.Task)
+		if !withDue {
+			fmt.Fprintf(tw, "%s\t%d\t%s\t\n", done, r.ID, taskLabel(r))
+
+			continue
+		}
+
+		// The due date is the last cell, so that the escape codes that
+		// highlight it do not count towards the alignment of the columns.
+		due := formatDue(r, now, color)
+		if due != "" {
+			due = "  " + due
+		}
+
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%s\n", done, r.ID, taskLabel(r), due)
 	}
 
 	return tw.Flush()
@@ -71,4 +436,22 @@ func printItems(w io.Writer, items []item) error {
 
 func init() {
 	rootCmd.AddCommand(listCmd)
+
+	listCmd.Flags().Bool("pending", false, "Only list pending items")
+	listCmd.Flags().Bool("done", false, "Only list completed items")
+	listCmd.Flags().String("search", "", "Only list items whose task contains the text, or matches /regex/")
+	listCmd.Flags().String("created-after", "", "Only list items created after the date")
+	listCmd.Flags().String("created-before", "", "Only list items created before the date")
+	listCmd.Flags().String("completed-since", "", "Only list items completed since the date")
+	listCmd.Flags().Bool("overdue", false, "Only list pending items past their due date")
+	listCmd.Flags().String("due-before", "", "Only list items due before the date")
+	listCmd.Flags().StringArray("tag", nil, "Only list items with the tag, can be repeated to require several")
+	listCmd.Flags().String("priority", "", "Only list items with the priority: high, medium or low")
+	listCmd.Flags().String("sort", "", "Sort items by created, completed, task, status, due or priority")
+	listCmd.Flags().Bool("reverse", false, "Reverse the order of the items")
+	listCmd.Flags().Bool("tree", false, "Show subtasks nested under their parent")
+	listCmd.Flags().Int("limit", 0, "Maximum number of items to list, fetching as many pages as needed")
+	listCmd.Flags().Int("page", 0, "Only list the items of the given page of a paged server")
+
+	addFormatFlags(listCmd)
 }
@@ -0,0 +1,179 @@
+/*
+Copyright © 2022 mycok <github.com/mycok>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// listsCmd represents the lists command
+var listsCmd = &cobra.Command{
+	Use:   "lists",
+	Short: "Show and manage the named todo lists",
+	Long: `Show the named todo lists with their item counts, or manage them with the
+subcommands.
+
+Every item command works on the list given with the global --list flag, which
+a profile can also set, or on the default list when there is none.`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		rootURL := viper.GetString("api-root")
+
+		return listsAction(
+			cmd.Context(), os.Stdout, rootURL, viper.GetString("list"),
+		)
+	},
+}
+
+var listsCreateCmd = &cobra.Command{
+	Use:          "create <name>",
+	Short:        "Create an empty list",
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		rootURL := viper.GetString("api-root")
+
+		return listsCreateAction(cmd.Context(), os.Stdout, rootURL, args[0])
+	},
+}
+
+var listsRenameCmd = &cobra.Command{
+	Use:          "rename <name> <newName>",
+	Short:        "Rename a list, keeping its items",
+	Args:         cobra.ExactArgs(2),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		rootURL := viper.GetString("api-root")
+
+		return listsRenameAction(
+			cmd.Context(), os.Stdout, rootURL, args[0], args[1],
+		)
+	},
+}
+
+var listsDeleteCmd = &cobra.Command{
+	Use:          "delete <name>",
+	Short:        "Delete a list and all of its items",
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		rootURL := viper.GetString("api-root")
+
+		return listsDeleteAction(cmd.Context(), os.Stdout, rootURL, args[0])
+	},
+}
+
+func listsAction(
+	ctx context.Context, w io.Writer, url, current string,
+) error {
+	c, err := newClient(url)
+	if err != nil {
+		return err
+	}
+
+	lists, err := c.Lists(ctx)
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 3, 2, 2, ' ', 0)
+
+	fmt.Fprintln(tw, "CURRENT\tNAME\tITEMS\tPENDING")
+
+	for _, l := range lists {
+		marker := ""
+		if l.Name == current {
+			marker = "*"
+		}
+
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%d\n", marker, l.Name, l.Total, l.Pending)
+	}
+
+	return tw.Flush()
+}
+
+func listsCreateAction(
+	ctx context.Context, w io.Writer, url, name string,
+) error {
+	c, err := newClient(url)
+	if err != nil {
+		return err
+	}
+
+	if err := c.CreateList(ctx, name); err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "Created list %q\n", name)
+
+	return err
+}
+
+func listsRenameAction(
+	ctx context.Context, w io.Writer, url, name, newName string,
+) error {
+	c, err := newClient(url)
+	if err != nil {
+		return err
+	}
+
+	if err := c.RenameList(ctx, name, newName); err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "Renamed list %q to %q\n", name, newName)
+
+	return err
+}
+
+func listsDeleteAction(
+	ctx context.Context, w io.Writer, url, name string,
+) error {
+	c, err := newClient(url)
+	if err != nil {
+		return err
+	}
+
+	if err := c.DeleteList(ctx, name); err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "Deleted list %q\n", name)
+
+	return err
+}
+
+func init() {
+	rootCmd.AddCommand(listsCmd)
+
+	listsCmd.AddCommand(listsCreateCmd)
+	listsCmd.AddCommand(listsRenameCmd)
+	listsCmd.AddCommand(listsDeleteCmd)
+}
@@ -0,0 +1,170 @@
+/*
+Copyright © 2022 mycok <github.com/mycok>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"golang.org/x/term"
+)
+
+var ErrNoSecret = errors.New("no secret given")
+
+// loginCmd represents the login command
+var loginCmd = &cobra.Command{
+	Use:   "login",
+	Short: "Save the credentials for the API",
+	Long: `Save a bearer token, or a password with --username, for the API set
+with --api-root. The secret is read from the terminal without echoing it,
+or from standard input when it is piped in, and stored under the user's
+config directory.
+
+The secret is encrypted with a key kept next to it, in a file only the user
+can read. This keeps it out of plain text, but is no protection against
+anyone who can read the user's files: it is obfuscation at rest, not a
+keyring.`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		rootURL := viper.GetString("api-root")
+
+		username, err := cmd.Flags().GetString("username")
+		if err != nil {
+			return err
+		}
+
+		store, err := defaultCredentialStore()
+		if err != nil {
+			return err
+		}
+
+		return loginAction(os.Stdout, os.Stdin, store, rootURL, username)
+	},
+}
+
+// logoutCmd represents the logout command
+var logoutCmd = &cobra.Command{
+	Use:          "logout",
+	Short:        "Remove the saved credentials for the API",
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		rootURL := viper.GetString("api-root")
+
+		store, err := defaultCredentialStore()
+		if err != nil {
+			return err
+		}
+
+		return logoutAction(os.Stdout, store, rootURL)
+	},
+}
+
+func loginAction(
+	w io.Writer, r io.Reader, store *credentialStore, url, username string,
+) error {
+	prompt := "Token: "
+	if username != "" {
+		prompt = "Password: "
+	}
+
+	secret, err := readSecret(r, prompt)
+	if err != nil {
+		return err
+	}
+
+	c := storedCredentials{Token: secret}
+	if username != "" {
+		c = storedCredentials{Username: username, Password: secret}
+	}
+
+	if err := store.set(url, c); err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "Credentials saved for %s\n", url)
+
+	return err
+}
+
+func logoutAction(w io.Writer, store *credentialStore, url string) error {
+	removed, err := store.remove(url)
+	if err != nil {
+		return err
+	}
+
+	if !removed {
+		_, err = fmt.Fprintf(w, "No credentials saved for %s\n", url)
+
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "Credentials removed for %s\n", url)
+
+	return err
+}
+
+// readSecret reads a secret from r, prompting for it without echo when r
+// is a terminal.
+func readSecret(r io.Reader, prompt string) (string, error) {
+	if f, ok := r.(*os.File); ok && term.IsTerminal(int(f.Fd())) {
+		fmt.Fprint(os.Stderr, prompt)
+		secret, err := term.ReadPassword(int(f.Fd()))
+		fmt.Fprintln(os.Stderr)
+
+		if err != nil {
+			return "", err
+		}
+
+		return checkSecret(string(secret))
+	}
+
+	line, err := bufio.NewReader(r).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+
+	return checkSecret(line)
+}
+
+func checkSecret(secret string) (string, error) {
+	secret = strings.TrimSpace(secret)
+	if secret == "" {
+		return "", ErrNoSecret
+	}
+
+	return secret, nil
+}
+
+func init() {
+	rootCmd.AddCommand(loginCmd)
+	rootCmd.AddCommand(logoutCmd)
+
+	loginCmd.Flags().String("username", "", "Save a username and password for basic authentication instead of a token")
+}
@@ -0,0 +1,98 @@
+//go:build !integration
+// +build !integration
+
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mycok/todo_list_client/client"
+	"github.com/spf13/viper"
+)
+
+func TestLoginAction(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+
+	store, err := defaultCredentialStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const url = "http://todo.example.com"
+
+	var out bytes.Buffer
+
+	err = loginAction(&out, strings.NewReader("s3cr3t\n"), store, url, "")
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %q instead", err)
+	}
+
+	expectedOutput := "Credentials saved for http://todo.example.com\n"
+	if expectedOutput != out.String() {
+		t.Errorf(
+			"Expected output: %s, but got: %s instead",
+			expectedOutput,
+			out.String(),
+		)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, appName, credentialsFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if bytes.Contains(data, []byte("s3cr3t")) {
+		t.Error("Expected the credentials file to be encrypted")
+	}
+
+	auth, err := newAuth(url)
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %q instead", err)
+	}
+
+	if auth != client.BearerToken("s3cr3t") {
+		t.Errorf("Expected the saved token, but got: %#v instead", auth)
+	}
+
+	out.Reset()
+
+	if err := logoutAction(&out, store, url); err != nil {
+		t.Fatalf("Expected no error, but got: %q instead", err)
+	}
+
+	if auth, _ := newAuth(url); auth != nil {
+		t.Errorf("Expected no credentials after logout, but got: %#v", auth)
+	}
+}
+
+func TestLoginActionNoSecret(t *testing.T) {
+	store := &credentialStore{dir: t.TempDir()}
+
+	err := loginAction(&bytes.Buffer{}, strings.NewReader("\n"), store, "u", "")
+	if !errors.Is(err, ErrNoSecret) {
+		t.Errorf("Expected error: %q, but got: %q instead", ErrNoSecret, err)
+	}
+}
+
+func TestNewAuthPrecedence(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("TODO_TOKEN", "from-env")
+
+	// Environment variables are read once the config is initialized.
+	viper.AutomaticEnv()
+
+	auth, err := newAuth("http://todo.example.com")
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %q instead", err)
+	}
+
+	if auth != client.BearerToken("from-env") {
+		t.Errorf("Expected the TODO_TOKEN token, but got: %#v instead", auth)
+	}
+}
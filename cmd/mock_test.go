@@ -1,10 +1,35 @@
 package cmd
 
 import (
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/mycok/todo_list_client/client"
 )
 
+// TestMain keeps the items cached, the operations queued and the responses
+// stored by the tests out of the state and cache directories of the user.
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "todo_list_client_state")
+	if err != nil {
+		panic(err)
+	}
+
+	os.Setenv("XDG_STATE_HOME", filepath.Join(dir, "state"))
+	os.Setenv("XDG_CACHE_HOME", filepath.Join(dir, "cache"))
+
+	code := m.Run()
+
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
 // Mock the todo-list API server responses to use for testing the client.
 var testResp = map[string]struct {
 	Status int
@@ -46,6 +71,58 @@ var testResp = map[string]struct {
 			"total_results": 1
 		}`,
 	},
+	"resultsMixed": {
+		Status: http.StatusOK,
+		Body: `{
+			"results": [
+				{
+					"Task": "write report",
+					"Done": true,
+					"CreatedAt": "2019-10-28T08:23:38.310097076-04:00",
+					"CompletedAt": "2019-10-30T10:00:00Z"
+				},
+				{
+					"Task": "fix login",
+					"Done": false,
+					"CreatedAt": "2019-10-29T08:23:38.310097076-04:00",
+					"CompletedAt": "0001-01-01T00:00:00Z"
+				},
+				{
+					"Task": "Deploy login service",
+					"Done": false,
+					"CreatedAt": "2019-10-27T08:23:38.310097076-04:00",
+					"CompletedAt": "0001-01-01T00:00:00Z"
+				}
+			],
+			"date": 356648847899,
+			"total_results": 3
+		}`,
+	},
+	"resultsIDs": {
+		Status: http.StatusOK,
+		Body: `{
+			"results": [
+				{
+					"id": 12,
+					"uuid": "5f0c6d1e-8b7a-4c3d-9e2f-1a0b9c8d7e6f",
+					"Task": "task 1",
+					"Done": false,
+					"CreatedAt": "2019-10-28T08:23:38.310097076-04:00",
+					"CompletedAt": "0001-01-01T00:00:00Z"
+				},
+				{
+					"id": 17,
+					"uuid": "9a8b7c6d-5e4f-4a3b-8c2d-1e0f9a8b7c6d",
+					"Task": "task 2",
+					"Done": true,
+					"CreatedAt": "2019-10-28T08:23:38.310097076-04:00",
+					"CompletedAt": "2019-10-29T08:23:38.310097076-04:00"
+				}
+			],
+			"date": 356648847899,
+			"total_results": 2
+		}`,
+	},
 	"noResults": {
 		Status: http.StatusOK,
 		Body: `{
@@ -79,3 +156,25 @@ func mockServer(h http.HandlerFunc) (string, func()) {
 		s.Close()
 	}
 }
+
+// serveItems answers a GET request like a server holding items that does
+// not filter on its side: /todo/{id} returns the item with that ID, and any
+// other path all of them.
+func serveItems(w http.ResponseWriter, r *http.Request, items []client.Item) {
+	if id, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/todo/")); err == nil {
+		for _, i := range items {
+			if i.ID == id {
+				json.NewEncoder(w).Encode(client.Response{Results: []client.Item{i}, TotalResults: 1})
+
+				return
+			}
+		}
+
+		w.WriteHeader(testResp["notFound"].Status)
+		w.Write([]byte(testResp["notFound"].Body))
+
+		return
+	}
+
+	json.NewEncoder(w).Encode(client.Response{Results: items, TotalResults: len(items)})
+}
@@ -0,0 +1,297 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+var ErrInvalidNotes = errors.New("invalid notes")
+
+// defaultWidth is the width text is wrapped to when the output is not a
+// terminal.
+const defaultWidth = 80
+
+// notesFromFlags returns the notes given with --note or --note-file, or else
+// piped into stdin. A terminal or an empty stdin gives no notes.
+func notesFromFlags(cmd *cobra.Command, stdin *os.File) (string, error) {
+	note, _ := cmd.Flags().GetString("note")
+	noteFile, _ := cmd.Flags().GetString("note-file")
+
+	switch {
+	case note != "" && noteFile != "":
+		return "", fmt.Errorf(
+			"%w: --note and --note-file cannot be used together", ErrInvalidNotes,
+		)
+
+	case note != "":
+		return note, nil
+
+	case noteFile != "":
+		data, err := os.ReadFile(noteFile)
+		if err != nil {
+			return "", fmt.Errorf("%w: %s", ErrInvalidNotes, err)
+		}
+
+		return strings.TrimSpace(string(data)), nil
+	}
+
+	// Only read stdin when something is redirected to it, so that a command
+	// run from a script without input does not wait for it.
+	info, err := stdin.Stat()
+	if err != nil {
+		return "", nil
+	}
+
+	if mode := info.Mode(); mode&os.ModeNamedPipe == 0 && !mode.IsRegular() {
+		return "", nil
+	}
+
+	data, err := io.ReadAll(stdin)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(string(data)), nil
+}
+
+// terminalWidth returns the width of w if it is a terminal, or
+// defaultWidth otherwise.
+func terminalWidth(w io.Writer) int {
+	if f, ok := w.(*os.File); ok && term.IsTerminal(int(f.Fd())) {
+		if width, _, err := term.GetSize(int(f.Fd())); err == nil && width > 0 {
+			return width
+		}
+	}
+
+	return defaultWidth
+}
+
+// wrapText wraps every line of text at width, keeping blank lines and the
+// indentation of each line on its continuation lines.
+func wrapText(text string, width int) []string {
+	var lines []string
+
+	for _, line := range strings.Split(text, "\n") {
+		indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+
+		lines = append(lines, wrapWords(
+			plainWords(strings.Fields(line)), indent, indent, width,
+		)...)
+	}
+
+	return lines
+}
+
+// word is a word of text with the width it takes on screen, which excludes
+// the escape codes that style it.
+type word struct {
+	text  string
+	width int
+}
+
+func plainWords(fields []string) []word {
+	words := make([]word, len(fields))
+
+	for i, f := range fields {
+		words[i] = word{f, utf8.RuneCountInString(f)}
+	}
+
+	return words
+}
+
+// wrapWords lays out words in lines of at most width columns, starting the
+// first one with prefix and the others with indent. Words longer than a line
+// get a line of their own.
+func wrapWords(words []word, prefix, indent string, width int) []string {
+	if len(words) == 0 {
+		return []string{strings.TrimRight(prefix, " ")}
+	}
+
+	var lines []string
+
+	line, lineWidth := prefix, utf8.RuneCountInString(prefix)
+	start := lineWidth
+
+	for _, w := range words {
+		if lineWidth > start && lineWidth+1+w.width > width {
+			lines = append(lines, line)
+			line, lineWidth = indent, utf8.RuneCountInString(indent)
+			start = lineWidth
+		}
+
+		if lineWidth > start {
+			line += " "
+			lineWidth++
+		}
+
+		line += w.text
+		lineWidth += w.width
+	}
+
+	return append(lines, line)
+}
+
+const (
+	styleBold      = "\x1b[1m"
+	styleItalic    = "\x1b[3m"
+	styleUnderline = "\x1b[4m"
+	styleCode      = "\x1b[36m"
+	styleDim       = "\x1b[2m"
+	styleReset     = "\x1b[0m"
+)
+
+var (
+	headingRe = regexp.MustCompile(`^(#{1,6})\s+(.*)$`)
+	bulletRe  = regexp.MustCompile(`^(\s*)[-*+]\s+(.*)$`)
+	orderedRe = regexp.MustCompile(`^(\s*)(\d+[.)])\s+(.*)$`)
+	quoteRe   = regexp.MustCompile(`^\s*>\s?(.*)$`)
+)
+
+// renderMarkdown renders the common Markdown blocks and inline styles of
+// text for the terminal: headings, lists, quotes, fenced code, bold,
+// italics and code spans. Markup is removed, and styles are only applied
+// when color is set.
+func renderMarkdown(text string, width int, color bool) []string {
+	style := func(codes, s string) string {
+		if !color || codes == "" {
+			return s
+		}
+
+		return codes + s + styleReset
+	}
+
+	var (
+		lines  []string
+		inCode bool
+	)
+
+	for _, line := range strings.Split(text, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inCode = !inCode
+
+			continue
+		}
+
+		if inCode {
+			lines = append(lines, "    "+style(styleDim, line))
+
+			continue
+		}
+
+		if m := headingRe.FindStringSubmatch(line); m != nil {
+			codes := styleBold
+			if len(m[1]) == 1 {
+				codes += styleUnderline
+			}
+
+			lines = append(lines, wrapWords(
+				inlineWords(m[2], codes, color), "", "", width,
+			)...)
+
+			continue
+		}
+
+		if m := bulletRe.FindStringSubmatch(line); m != nil {
+			lines = append(lines, wrapWords(
+				inlineWords(m[2], "", color), m[1]+"• ", m[1]+"  ", width,
+			)...)
+
+			continue
+		}
+
+		if m := orderedRe.FindStringSubmatch(line); m != nil {
+			indent := m[1] + strings.Repeat(" ", utf8.RuneCountInString(m[2])+1)
+
+			lines = append(lines, wrapWords(
+				inlineWords(m[3], "", color), m[1]+m[2]+" ", indent, width,
+			)...)
+
+			continue
+		}
+
+		if m := quoteRe.FindStringSubmatch(line); m != nil {
+			lines = append(lines, wrapWords(
+				inlineWords(m[1], styleItalic, color), "│ ", "│ ", width,
+			)...)
+
+			continue
+		}
+
+		lines = append(lines, wrapWords(inlineWords(line, "", color), "", "", width)...)
+	}
+
+	return lines
+}
+
+// inlineMarkers maps the inline Markdown markers to their style, longest
+// first so that ** is not read as two *.
+var inlineMarkers = []struct {
+	marker string
+	codes  string
+}{
+	{"**", styleBold},
+	{"__", styleBold},
+	{"`", styleCode},
+	{"*", styleItalic},
+	{"_", styleItalic},
+}
+
+// inlineWords splits line into words, removing the inline markers and, with
+// color, styling the words between them. base is applied to every word.
+func inlineWords(line, base string, color bool) []word {
+	active := make(map[string]bool)
+
+	var words []word
+
+	for _, f := range strings.Fields(line) {
+		for _, m := range inlineMarkers {
+			if len(f) > len(m.marker) && strings.HasPrefix(f, m.marker) && !active[m.marker] {
+				active[m.marker] = true
+				f = f[len(m.marker):]
+
+				break
+			}
+		}
+
+		closed := ""
+
+		for _, m := range inlineMarkers {
+			if active[m.marker] && strings.HasSuffix(strings.TrimRight(f, ".,;:!?)"), m.marker) {
+				trimmed := strings.TrimRight(f, ".,;:!?)")
+				f = trimmed[:len(trimmed)-len(m.marker)] + f[len(trimmed):]
+				closed = m.marker
+
+				break
+			}
+		}
+
+		codes := base
+
+		for _, m := range inlineMarkers {
+			if active[m.marker] {
+				codes += m.codes
+			}
+		}
+
+		if closed != "" {
+			active[closed] = false
+		}
+
+		text := f
+		if color && codes != "" {
+			text = codes + f + styleReset
+		}
+
+		words = append(words, word{text, utf8.RuneCountInString(f)})
+	}
+
+	return words
+}
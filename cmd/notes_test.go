@@ -0,0 +1,304 @@
+//go:build !integration
+// +build !integration
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/mycok/todo_list_client/client"
+)
+
+func TestWrapText(t *testing.T) {
+	testCases := []struct {
+		name     string
+		text     string
+		width    int
+		expected []string
+	}{
+		{name: "Short", text: "one two", width: 20, expected: []string{"one two"}},
+		{
+			name:     "Wrapped",
+			text:     "one two three four",
+			width:    9,
+			expected: []string{"one two", "three", "four"},
+		},
+		{
+			name:     "Paragraphs",
+			text:     "one two\n\nthree",
+			width:    20,
+			expected: []string{"one two", "", "three"},
+		},
+		{
+			name:     "Indented",
+			text:     "  one two three",
+			width:    9,
+			expected: []string{"  one two", "  three"},
+		},
+		{
+			name:     "LongWord",
+			text:     "a supercalifragilistic word",
+			width:    8,
+			expected: []string{"a", "supercalifragilistic", "word"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			lines := wrapText(tc.text, tc.width)
+
+			if !reflect.DeepEqual(tc.expected, lines) {
+				t.Errorf("Expected lines: %q, but got: %q instead", tc.expected, lines)
+			}
+		})
+	}
+}
+
+func TestRenderMarkdown(t *testing.T) {
+	text := "# Deploy\n\n" +
+		"Run the **release** script, then check `status`.\n\n" +
+		"- build the image\n" +
+		"1. push it\n" +
+		"> be careful\n\n" +
+		"```\n" +
+		"make deploy\n" +
+		"```"
+
+	testCases := []struct {
+		name     string
+		color    bool
+		width    int
+		expected []string
+	}{
+		{
+			name:  "Plain",
+			width: 80,
+			expected: []string{
+				"Deploy",
+				"",
+				"Run the release script, then check status.",
+				"",
+				"• build the image",
+				"1. push it",
+				"│ be careful",
+				"",
+				"    make deploy",
+			},
+		},
+		{
+			name:  "Wrapped",
+			width: 20,
+			expected: []string{
+				"Deploy",
+				"",
+				"Run the release",
+				"script, then check",
+				"status.",
+				"",
+				"• build the image",
+				"1. push it",
+				"│ be careful",
+				"",
+				"    make deploy",
+			},
+		},
+		{
+			name:  "Color",
+			color: true,
+			width: 80,
+			expected: []string{
+				styleBold + styleUnderline + "Deploy" + styleReset,
+				"",
+				"Run the " + styleBold + "release" + styleReset +
+					" script, then check " + styleCode + "status." + styleReset,
+				"",
+				"• build the image",
+				"1. push it",
+				"│ " + styleItalic + "be" + styleReset + " " +
+					styleItalic + "careful" + styleReset,
+				"",
+				"    " + styleDim + "make deploy" + styleReset,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			lines := renderMarkdown(text, tc.width, tc.color)
+
+			if !reflect.DeepEqual(tc.expected, lines) {
+				t.Errorf("Expected lines: %q, but got: %q instead", tc.expected, lines)
+			}
+		})
+	}
+}
+
+func TestNotesFromFlags(t *testing.T) {
+	noteFile := filepath.Join(t.TempDir(), "notes.md")
+
+	if err := os.WriteFile(noteFile, []byte("\nfrom a file\n\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	pipeFile := filepath.Join(t.TempDir(), "stdin")
+
+	if err := os.WriteFile(pipeFile, []byte("piped in\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name        string
+		note        string
+		noteFile    string
+		stdin       string
+		expected    string
+		expectedErr error
+	}{
+		{name: "Note", note: "a note", expected: "a note"},
+		{name: "NoteFile", noteFile: noteFile, expected: "from a file"},
+		{name: "Stdin", stdin: pipeFile, expected: "piped in"},
+		{name: "NoteOverStdin", note: "a note", stdin: pipeFile, expected: "a note"},
+		{
+			name:        "Both",
+			note:        "a note",
+			noteFile:    noteFile,
+			expectedErr: ErrInvalidNotes,
+		},
+		{
+			name:        "MissingFile",
+			noteFile:    filepath.Join(t.TempDir(), "missing.md"),
+			expectedErr: ErrInvalidNotes,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			note, noteFile := addCmd.Flags().Lookup("note"), addCmd.Flags().Lookup("note-file")
+
+			defer resetFlag(note)
+			defer resetFlag(noteFile)
+
+			note.Value.Set(tc.note)
+			noteFile.Value.Set(tc.noteFile)
+
+			stdin := os.Stdin
+
+			if tc.stdin != "" {
+				f, err := os.Open(tc.stdin)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				defer f.Close()
+
+				stdin = f
+			}
+
+			notes, err := notesFromFlags(addCmd, stdin)
+
+			if tc.expectedErr != nil {
+				if !errors.Is(err, tc.expectedErr) {
+					t.Errorf("Expected error: %q, but got: %q instead", tc.expectedErr, err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Expected no error, but got: %q instead", err)
+			}
+
+			if tc.expected != notes {
+				t.Errorf("Expected notes: %q, but got: %q instead", tc.expected, notes)
+			}
+		})
+	}
+}
+
+func TestNotesActions(t *testing.T) {
+	var added client.NewItem
+
+	url, cleanup := mockServer(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			if err := json.NewDecoder(r.Body).Decode(&added); err != nil {
+				t.Fatalf("Expected a JSON request body, got error: %v", err)
+			}
+
+			w.WriteHeader(testResp["created"].Status)
+
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"results": [{
+			"Task": "deploy",
+			"CreatedAt": "2019-10-28T08:23:38.310097076-04:00",
+			"Notes": "Run the release script and then check the dashboards for errors.\n\n- roll back on failure"
+		}]}`))
+	})
+
+	defer cleanup()
+
+	var out bytes.Buffer
+
+	err := addAction(
+		context.Background(), &out, url, []string{"deploy"},
+		addOptions{notes: "check the dashboards"},
+	)
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %q instead", err)
+	}
+
+	if added.Notes != "check the dashboards" {
+		t.Errorf("Expected notes: %q, but got: %q instead", "check the dashboards", added.Notes)
+	}
+
+	testCases := []struct {
+		name     string
+		markdown bool
+		expected string
+	}{
+		{
+			name: "Text",
+			expected: "\nNotes:\n" +
+				"  Run the release script and then check the dashboards for errors.\n" +
+				"\n" +
+				"  - roll back on failure\n",
+		},
+		{
+			name:     "Markdown",
+			markdown: true,
+			expected: "\nNotes:\n" +
+				"  Run the release script and then check the dashboards for errors.\n" +
+				"\n" +
+				"  • roll back on failure\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var out bytes.Buffer
+
+			err := viewAction(
+				context.Background(), &out, url, "1", textFormatter{markdown: tc.markdown},
+			)
+			if err != nil {
+				t.Fatalf("Expected no error, but got: %q instead", err)
+			}
+
+			_, notes, _ := bytes.Cut(out.Bytes(), []byte("Completed:    No\n"))
+
+			if tc.expected != string(notes) {
+				t.Errorf("Expected notes: %q, but got: %q instead", tc.expected, notes)
+			}
+		})
+	}
+}
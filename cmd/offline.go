@@ -0,0 +1,772 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/mycok/todo_list_client/client"
+	"github.com/mycok/todo_list_client/internal/fsutil"
+)
+
+var ErrConflict = errors.New("conflicting operations")
+
+const (
+	offlineCacheFile = "items.json"
+	outboxFile       = "outbox.json"
+	outboxLockFile   = "outbox.lock"
+)
+
+// The outbox lock is waited for up to outboxLockWait. A lock older than
+// outboxLockStale was left by a command that did not finish, and is broken.
+const (
+	outboxLockWait  = 5 * time.Second
+	outboxLockStale = time.Minute
+)
+
+// The mutations that can be queued in the outbox.
+const (
+	opAdd      = "add"
+	opComplete = "complete"
+	opDelete   = "delete"
+)
+
+// stderr receives the notices about offline mode and the warnings of the
+// commands, which must not mix with their output.
+var stderr io.Writer = os.Stderr
+
+// errOutboxPending is returned instead of sending a mutation while earlier
+// ones are waiting in the outbox, so that the mutations reach the API in
+// the order they were made.
+var errOutboxPending = fmt.Errorf(
+	"%w: earlier operations are waiting in the outbox", client.ErrConnection,
+)
+
+// cachedList is the last full list of items fetched from an API.
+type cachedList struct {
+	APIRoot   string        `json:"api_root"`
+	List      string        `json:"list,omitempty"`
+	FetchedAt time.Time     `json:"fetched_at"`
+	Items     []client.Item `json:"items"`
+}
+
+// operation is a mutation that may end up waiting in the outbox, when the
+// API is unreachable, to be sent later. Key is sent as the idempotency key
+// of every attempt, the first one included, so that an operation the API
+// received before the connection was lost is not applied twice.
+//
+// On servers without IDs, ItemID is a position, which the operations sent
+// before this one can shift. Task then holds the task of the item at that
+// position when the operation was queued, and the operation is only sent if
+// the item there still has it.
+//
+// Done, for an add, marks the item as completed once added, as import does
+// with the completed items of a file.
+type operation struct {
+	Key      string          `json:"key"`
+	Op       string          `json:"op"`
+	ItemID   int             `json:"item_id,omitempty"`
+	Task     string          `json:"task,omitempty"`
+	Item     *client.NewItem `json:"item,omitempty"`
+	Done     bool            `json:"done,omitempty"`
+	QueuedAt time.Time       `json:"queued_at"`
+}
+
+func (op operation) String() string {
+	if op.Op == opAdd {
+		return fmt.Sprintf("add %q", op.Item.Task)
+	}
+
+	return fmt.Sprintf("%s item number %d", op.Op, op.ItemID)
+}
+
+// send applies op through c.
+func (op operation) send(ctx context.Context, c *client.Client) error {
+	ctx = client.WithIdempotencyKey(ctx, op.Key)
+
+	if op.Task != "" {
+		item, err := c.Get(ctx, op.ItemID)
+		if err != nil {
+			return err
+		}
+
+		if item.Task != op.Task {
+			return fmt.Errorf(
+				"%w: the item at position %d is now %q, not %q",
+				ErrConflict, op.ItemID, item.Task, op.Task,
+			)
+		}
+	}
+
+	switch op.Op {
+	case opAdd:
+		if err := c.AddItem(ctx, *op.Item); err != nil || !op.Done {
+			return err
+		}
+
+		// The completion is a request of its own, which must not be
+		// taken for a retry of the add.
+		ctx = client.WithIdempotencyKey(ctx, op.Key+"-complete")
+
+		return completeAdded(ctx, c, op.Item.Task)
+	case opComplete:
+		return c.Complete(ctx, op.ItemID)
+	case opDelete:
+		return c.Delete(ctx, op.ItemID)
+	}
+
+	return fmt.Errorf("unknown operation %q", op.Op)
+}
+
+// completeAdded marks as done the item just added with task: the newest of
+// the pending items that have it. Sending the add again when this fails does
+// not add the item twice, as the API recognizes its idempotency key.
+func completeAdded(ctx context.Context, c *client.Client, task string) error {
+	pending := false
+
+	items, err := c.Find(ctx, client.Query{Done: &pending, Search: task})
+	if err != nil && !errors.Is(err, client.ErrNotFound) {
+		return err
+	}
+
+	ref := 0
+
+	for _, i := range items {
+		if i.Task == task && i.Ref() > ref {
+			ref = i.Ref()
+		}
+	}
+
+	if ref == 0 {
+		return fmt.Errorf("%w: %q was added, but not found to complete it", ErrConflict, task)
+	}
+
+	return c.Complete(ctx, ref)
+}
+
+func newOperation(op string, id int, item *client.NewItem) (operation, error) {
+	key := make([]byte, 16)
+
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return operation{}, err
+	}
+
+	return operation{
+		Key:      hex.EncodeToString(key),
+		Op:       op,
+		ItemID:   id,
+		Item:     item,
+		QueuedAt: time.Now(),
+	}, nil
+}
+
+// offlineStore keeps, for one API and list, the last full list of items
+// fetched and the outbox of mutations made while the API was unreachable.
+type offlineStore struct {
+	dir string
+}
+
+// stateDir returns the directory the offline data is kept in,
+// $XDG_STATE_HOME/todo_list_client, with XDG_STATE_HOME defaulting to
+// ~/.local/state.
+func stateDir() (string, error) {
+	stateHome := os.Getenv("XDG_STATE_HOME")
+
+	if stateHome == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+
+		stateHome = filepath.Join(home, ".local", "state")
+	}
+
+	return filepath.Join(stateHome, appName), nil
+}
+
+// offlineStoreFor returns the store for the API and list c works on.
+func offlineStoreFor(c *client.Client) (*offlineStore, error) {
+	dir, err := stateDir()
+	if err != nil {
+		return nil, err
+	}
+
+	sum := sha256.Sum256([]byte(c.BaseURL + "\n" + c.ListName))
+
+	return &offlineStore{
+		dir: filepath.Join(dir, "offline", hex.EncodeToString(sum[:8])),
+	}, nil
+}
+
+func (s *offlineStore) loadList() (cachedList, bool, error) {
+	var list cachedList
+
+	ok, err := s.read(offlineCacheFile, &list)
+	if !ok || err != nil {
+		return list, false, err
+	}
+
+	// Positions are not saved, but the items are saved in order.
+	for i := range list.Items {
+		list.Items[i].Position = i + 1
+	}
+
+	return list, true, nil
+}
+
+func (s *offlineStore) saveList(list cachedList) error {
+	return s.write(offlineCacheFile, list)
+}
+
+// pending returns the operations waiting in the outbox, oldest first.
+func (s *offlineStore) pending() ([]operation, error) {
+	var ops []operation
+
+	_, err := s.read(outboxFile, &ops)
+
+	return ops, err
+}
+
+// setPending replaces the operations in the outbox with ops. Callers hold
+// the outbox lock.
+func (s *offlineStore) setPending(ops []operation) error {
+	if len(ops) == 0 {
+		err := os.Remove(filepath.Join(s.dir, outboxFile))
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+
+		return err
+	}
+
+	return s.write(outboxFile, ops)
+}
+
+// queue appends ops to the outbox.
+func (s *offlineStore) queue(ops ...operation) error {
+	return s.update(func(pending []operation) []operation {
+		return append(pending, ops...)
+	})
+}
+
+// remove takes the operation with the given key out of the outbox.
+func (s *offlineStore) remove(key string) error {
+	return s.update(func(pending []operation) []operation {
+		var kept []operation
+
+		for _, op := range pending {
+			if op.Key != key {
+				kept = append(kept, op)
+			}
+		}
+
+		return kept
+	})
+}
+
+// update replaces the operations in the outbox with the ones change returns
+// for them, holding the outbox lock so that commands running at the same
+// time do not lose each other's changes.
+func (s *offlineStore) update(change func(pending []operation) []operation) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+
+	defer unlock()
+
+	pending, err := s.pending()
+	if err != nil {
+		return err
+	}
+
+	return s.setPending(change(pending))
+}
+
+// lock takes the outbox lock, a file only one command can create, waiting
+// for the command holding it. It returns the function that releases it.
+func (s *offlineStore) lock() (func(), error) {
+	if err := os.MkdirAll(s.dir, 0o700); err != nil {
+		return nil, err
+	}
+
+	path := filepath.Join(s.dir, outboxLockFile)
+	deadline := time.Now().Add(outboxLockWait)
+
+	for {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+		if err == nil {
+			f.Close()
+
+			return func() { os.Remove(path) }, nil
+		}
+
+		if !errors.Is(err, fs.ErrExist) {
+			return nil, err
+		}
+
+		if info, err := os.Stat(path); err == nil && time.Since(info.ModTime()) > outboxLockStale {
+			os.Remove(path)
+
+			continue
+		}
+
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("the outbox is locked by another command: %s", path)
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func (s *offlineStore) read(name string, v interface{}) (bool, error) {
+	data, err := os.ReadFile(filepath.Join(s.dir, name))
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, err
+	}
+
+	if err := json.Unmarshal(data, v); err != nil {
+		return false, fmt.Errorf("%s is corrupted: %w", filepath.Join(s.dir, name), err)
+	}
+
+	return true, nil
+}
+
+func (s *offlineStore) write(name string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return fsutil.WriteFileAtomic(filepath.Join(s.dir, name), data, 0o600)
+}
+
+// listAll returns all the items in the list of c, with no error for an
+// empty list, and caches them. When the API is unreachable, it returns the
+// cached items instead.
+func listAll(ctx context.Context, c *client.Client) ([]client.Item, error) {
+	items, err := c.List(ctx)
+
+	switch {
+	case errors.Is(err, client.ErrNotFound):
+		items = nil
+	case errors.Is(err, client.ErrConnection):
+		return cachedItems(c, err)
+	case err != nil:
+		return nil, err
+	}
+
+	cacheItems(c, items)
+
+	return items, nil
+}
+
+// peekAll is like listAll, but leaves the cached list as it is.
+func peekAll(ctx context.Context, c *client.Client) ([]client.Item, error) {
+	items, err := c.List(ctx)
+
+	switch {
+	case errors.Is(err, client.ErrNotFound):
+		return nil, nil
+	case errors.Is(err, client.ErrConnection):
+		return cachedItems(c, err)
+	}
+
+	return items, err
+}
+
+// findItem returns the item of items identified by id, or err if there is
+// none.
+func findItem(items []client.Item, id int, err error) (client.Item, error) {
+	for _, i := range items {
+		if i.Ref() == id {
+			return i, nil
+		}
+	}
+
+	return client.Item{}, err
+}
+
+// cacheItems saves items as the full list of c. Caching is best effort,
+// and failures are ignored.
+func cacheItems(c *client.Client, items []client.Item) {
+	s, err := offlineStoreFor(c)
+	if err != nil {
+		return
+	}
+
+	s.saveList(cachedList{
+		APIRoot:   c.BaseURL,
+		List:      c.ListName,
+		FetchedAt: time.Now(),
+		Items:     items,
+	})
+}
+
+// listCache writes the full list of a client to its cache as the items come
+// in, so that a list streamed to the output is cached without being held in
+// memory. Caching is best effort: a nil *listCache caches nothing, and the
+// cache is left as it was unless commit succeeds.
+type listCache struct {
+	f    *fsutil.AtomicFile
+	n    int
+	err  error
+	done bool
+}
+
+// newListCache returns a listCache for the list of c, or nil if the cache
+// cannot be written.
+func newListCache(c *client.Client) *listCache {
+	s, err := offlineStoreFor(c)
+	if err != nil {
+		return nil
+	}
+
+	// The items are written between the brackets of the empty list, the
+	// last field of a cachedList.
+	header, err := json.Marshal(cachedList{
+		APIRoot:   c.BaseURL,
+		List:      c.ListName,
+		FetchedAt: time.Now(),
+		Items:     []client.Item{},
+	})
+	if err != nil {
+		return nil
+	}
+
+	f, err := fsutil.CreateAtomic(filepath.Join(s.dir, offlineCacheFile), 0o600)
+	if err != nil {
+		return nil
+	}
+
+	lc := &listCache{f: f}
+	_, lc.err = f.Write(bytes.TrimSuffix(header, []byte("]}")))
+
+	return lc
+}
+
+// add writes item to the cache.
+func (lc *listCache) add(item client.Item) {
+	if lc == nil || lc.err != nil {
+		return
+	}
+
+	data, err := json.Marshal(item)
+	if err != nil {
+		lc.err = err
+
+		return
+	}
+
+	if lc.n > 0 {
+		data = append([]byte(","), data...)
+	}
+
+	lc.n++
+	_, lc.err = lc.f.Write(data)
+}
+
+// commit replaces the cached list with the items added.
+func (lc *listCache) commit() {
+	if lc == nil || lc.done {
+		return
+	}
+
+	lc.done = true
+
+	if lc.err == nil {
+		_, lc.err = lc.f.Write([]byte("]}"))
+	}
+
+	if lc.err != nil {
+		lc.f.Abort()
+
+		return
+	}
+
+	lc.f.Commit()
+}
+
+// abort leaves the cached list as it was, unless commit was called first.
+func (lc *listCache) abort() {
+	if lc == nil || lc.done {
+		return
+	}
+
+	lc.done = true
+	lc.f.Abort()
+}
+
+// cachedItems returns the cached list of c, after telling that the items
+// are stale. It returns err, the error that prevented fetching the list,
+// when nothing is cached.
+func cachedItems(c *client.Client, err error) ([]client.Item, error) {
+	s, storeErr := offlineStoreFor(c)
+	if storeErr != nil {
+		return nil, err
+	}
+
+	list, ok, loadErr := s.loadList()
+	if loadErr != nil || !ok {
+		return nil, err
+	}
+
+	fmt.Fprintf(
+		stderr, "API unreachable, showing cached items, stale since %s\n",
+		list.FetchedAt.Local().Format(dueFormat),
+	)
+
+	return list.Items, nil
+}
+
+// resumeOutbox sends the operations waiting in the outbox of c, if any,
+// before a command makes new requests. The outcome is written to stderr.
+func resumeOutbox(ctx context.Context, c *client.Client) {
+	s, err := offlineStoreFor(c)
+	if err != nil {
+		return
+	}
+
+	if ops, err := s.pending(); err != nil || len(ops) == 0 {
+		return
+	}
+
+	syncOutbox(ctx, stderr, c, s)
+}
+
+// outboxPending reports whether operations are waiting in the outbox of c.
+func outboxPending(c *client.Client) bool {
+	s, err := offlineStoreFor(c)
+	if err != nil {
+		return false
+	}
+
+	ops, err := s.pending()
+
+	return err == nil && len(ops) > 0
+}
+
+// sendOrQueue sends op, unless operations are already waiting in the
+// outbox of c, and queues it when the API cannot be reached. It reports
+// whether op was queued.
+func sendOrQueue(ctx context.Context, c *client.Client, op operation) (bool, error) {
+	err := errOutboxPending
+
+	if !outboxPending(c) {
+		err = op.send(ctx, c)
+	}
+
+	if !errors.Is(err, client.ErrConnection) {
+		return false, err
+	}
+
+	s, storeErr := offlineStoreFor(c)
+	if storeErr != nil {
+		return false, err
+	}
+
+	if err := s.queue(op); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// batchOrQueue runs send for the items in ids, unless operations are
+// already waiting in the outbox of c, and queues an op operation for each
+// item the API could not be reached for. It returns the results of the
+// other items and the IDs of the queued items. On servers without IDs, an
+// item is only queued if the cached list tells which task is at its
+// position, for the operation to be checked against it when sent.
+func batchOrQueue(
+	c *client.Client, ids []int, op string,
+	send func(ids []int) ([]client.Result, error),
+) ([]client.Result, []int, error) {
+	var results []client.Result
+
+	if outboxPending(c) {
+		for _, id := range ids {
+			results = append(results, client.Result{ID: id, Err: errOutboxPending})
+		}
+	} else {
+		results, _ = send(ids)
+	}
+
+	var (
+		sent   []client.Result
+		ops    []operation
+		queued []int
+	)
+
+	s, storeErr := offlineStoreFor(c)
+
+	var cached []client.Item
+
+	if storeErr == nil {
+		if list, ok, err := s.loadList(); err == nil && ok {
+			cached = list.Items
+		}
+	}
+
+	byPosition := len(cached) == 0 || cached[0].ID == 0
+
+	for _, r := range results {
+		if !errors.Is(r.Err, client.ErrConnection) {
+			sent = append(sent, r)
+
+			continue
+		}
+
+		o, err := newOperation(op, r.ID, nil)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if byPosition {
+			if r.ID > len(cached) {
+				sent = append(sent, client.Result{
+					ID: r.ID,
+					Err: fmt.Errorf(
+						"%w, and the item at position %d is not cached to be queued",
+						r.Err, r.ID,
+					),
+				})
+
+				continue
+			}
+
+			o.Task = cached[r.ID-1].Task
+		}
+
+		ops = append(ops, o)
+		queued = append(queued, r.ID)
+	}
+
+	if len(ops) == 0 {
+		return sent, nil, nil
+	}
+
+	if storeErr != nil {
+		return nil, nil, storeErr
+	}
+
+	return sent, queued, s.queue(ops...)
+}
+
+// isConflict reports whether err tells that an operation conflicts with the
+// state of the server, so that sending it again cannot succeed: the item is
+// gone, the server refused the change as conflicting, or the item at its
+// position is not the one the operation was queued for.
+func isConflict(err error) bool {
+	return errors.Is(err, client.ErrNotFound) ||
+		errors.Is(err, client.ErrConflict) ||
+		errors.Is(err, ErrConflict)
+}
+
+// resultsError returns the *client.BatchError of the failed results, or nil
+// if they all succeeded.
+func resultsError(results []client.Result) error {
+	batchErr := &client.BatchError{Total: len(results)}
+
+	for _, r := range results {
+		if r.Err != nil {
+			batchErr.Failed = append(batchErr.Failed, r)
+		}
+	}
+
+	if len(batchErr.Failed) == 0 {
+		return nil
+	}
+
+	return batchErr
+}
+
+// printQueued tells that the items in ids are queued to be changed as
+// described by action.
+func printQueued(w io.Writer, ids []int, action string) error {
+	for _, id := range ids {
+		if _, err := fmt.Fprintf(
+			w, "API unreachable, item number %d queued to be %s\n", id, action,
+		); err != nil {
+			return err
+		}
+	}
+
+	if len(ids) == 0 {
+		return nil
+	}
+
+	_, err := fmt.Fprintln(w, "Run sync to send the queued operations")
+
+	return err
+}
+
+// syncOutbox sends the operations waiting in the outbox of c in order,
+// writing a line for each to w. Operations that conflict with the state of
+// the server, such as completing an item deleted in the meantime, are
+// dropped. Any other error, from an unreachable API to a server error or a
+// cancelled command, stops the sync and keeps the operation and the ones
+// after it in the outbox. It returns the number of operations sent, in
+// conflict and left.
+func syncOutbox(
+	ctx context.Context, w io.Writer, c *client.Client, s *offlineStore,
+) (int, int, int, error) {
+	ops, err := s.pending()
+	if err != nil {
+		return 0, 0, 0, err
+	}
+
+	sent, conflicts := 0, 0
+
+	for i, op := range ops {
+		err := op.send(ctx, c)
+
+		switch {
+		case err == nil:
+			sent++
+
+			fmt.Fprintf(w, "Sent: %s\n", op)
+
+		case isConflict(err):
+			conflicts++
+
+			fmt.Fprintf(w, "Conflict: %s: %s\n", op, err)
+
+		case errors.Is(err, client.ErrConnection):
+			fmt.Fprintf(
+				w, "API unreachable, %d queued operations left\n", len(ops)-i,
+			)
+
+			return sent, conflicts, len(ops) - i, err
+
+		default:
+			fmt.Fprintf(
+				w, "Stopped at %s: %s, %d queued operations left\n", op, err, len(ops)-i,
+			)
+
+			return sent, conflicts, len(ops) - i, err
+		}
+
+		// Every operation leaves the outbox as soon as it is done with,
+		// so that an interrupted sync does not send it again.
+		if err := s.remove(op.Key); err != nil {
+			return sent, conflicts, len(ops) - i - 1, err
+		}
+	}
+
+	return sent, conflicts, 0, nil
+}
@@ -0,0 +1,479 @@
+//go:build !integration
+// +build !integration
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/mycok/todo_list_client/client"
+	"github.com/spf13/viper"
+)
+
+func TestOfflineMode(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+
+	// Connection errors are not retried, to keep the test fast.
+	retries := viper.Get("retries")
+	viper.Set("retries", 0)
+
+	defer viper.Set("retries", retries)
+
+	var notices bytes.Buffer
+
+	stderr = &notices
+	defer func() { stderr = os.Stderr }()
+
+	var (
+		// offline is read by the handler and set by the test, and is only
+		// accessed atomically.
+		offline  int32
+		requests []string
+		keys     []string
+	)
+
+	items := []client.Item{
+		{ID: 1, Task: "write report"},
+		{ID: 2, Task: "call mum"},
+		{ID: 3, Task: "water plants"},
+	}
+
+	url, cleanup := mockServer(func(w http.ResponseWriter, r *http.Request) {
+		// An unreachable API drops the connection.
+		if atomic.LoadInt32(&offline) == 1 {
+			conn, _, err := w.(http.Hijacker).Hijack()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			conn.Close()
+
+			return
+		}
+
+		requests = append(requests, r.Method+" "+r.URL.Path)
+
+		if key := r.Header.Get(client.IdempotencyKeyHeader); key != "" {
+			keys = append(keys, key)
+		}
+
+		switch {
+		case r.Method == http.MethodPost:
+			w.WriteHeader(testResp["created"].Status)
+		case r.Method == http.MethodDelete:
+			w.WriteHeader(testResp["notFound"].Status)
+			w.Write([]byte(testResp["notFound"].Body))
+		case r.Method == http.MethodPatch:
+			w.WriteHeader(testResp["noContent"].Status)
+		default:
+			json.NewEncoder(w).Encode(client.Response{Results: items, TotalResults: len(items)})
+		}
+	})
+
+	defer cleanup()
+
+	ctx := context.Background()
+
+	var body bytes.Buffer
+
+	// A successful list is cached.
+	if err := listAction(ctx, &body, url, jsonPathFormatter{mustParseJSONPath(t, "{[*].id}")}, listOptions{}); err != nil {
+		t.Fatalf("Expected no error, but got: %q instead", err)
+	}
+
+	atomic.StoreInt32(&offline, 1)
+
+	t.Run("ReadFromCache", func(t *testing.T) {
+		notices.Reset()
+		body.Reset()
+
+		f := jsonPathFormatter{mustParseJSONPath(t, "{[*].id}")}
+		opts := listOptions{query: client.Query{Search: "a"}}
+
+		if err := listAction(ctx, &body, url, f, opts); err != nil {
+			t.Fatalf("Expected no error, but got: %q instead", err)
+		}
+
+		if body.String() != "2 3\n" {
+			t.Errorf("Expected output: %q, but got: %q instead", "2 3\n", body.String())
+		}
+
+		body.Reset()
+
+		if err := viewAction(ctx, &body, url, "#2", jsonPathFormatter{mustParseJSONPath(t, "{.task}")}); err != nil {
+			t.Fatalf("Expected no error, but got: %q instead", err)
+		}
+
+		if body.String() != "call mum\n" {
+			t.Errorf("Expected output: %q, but got: %q instead", "call mum\n", body.String())
+		}
+
+		if !strings.Contains(notices.String(), "showing cached items, stale since") {
+			t.Errorf("Expected a stale banner, but got: %q instead", notices.String())
+		}
+	})
+
+	t.Run("Queue", func(t *testing.T) {
+		body.Reset()
+
+		if err := addAction(ctx, &body, url, []string{"buy milk"}, addOptions{}); err != nil {
+			t.Fatalf("Expected no error, but got: %q instead", err)
+		}
+
+		if err := completeAction(ctx, &body, url, []string{"1"}, false); err != nil {
+			t.Fatalf("Expected no error, but got: %q instead", err)
+		}
+
+		if err := deleteAction(ctx, &body, url, []string{"#3"}); err != nil {
+			t.Fatalf("Expected no error, but got: %q instead", err)
+		}
+
+		expectedOutput := "API unreachable, item queued to be added: buy milk\n" +
+			"Run sync to send the queued operations\n" +
+			"API unreachable, item number 1 queued to be completed\n" +
+			"Run sync to send the queued operations\n" +
+			"API unreachable, item number 3 queued to be deleted\n" +
+			"Run sync to send the queued operations\n"
+
+		if expectedOutput != body.String() {
+			t.Errorf("Expected output: %q, but got: %q instead", expectedOutput, body.String())
+		}
+	})
+
+	t.Run("SyncUnreachable", func(t *testing.T) {
+		body.Reset()
+
+		err := syncAction(ctx, &body, url)
+		if !errors.Is(err, client.ErrConnection) {
+			t.Errorf("Expected error: %q, but got: %q instead", client.ErrConnection, err)
+		}
+
+		expectedOutput := "API unreachable, 3 queued operations left\n" +
+			"Sent 0 operations, 0 conflicts, 3 left\n"
+
+		if expectedOutput != body.String() {
+			t.Errorf("Expected output: %q, but got: %q instead", expectedOutput, body.String())
+		}
+	})
+
+	atomic.StoreInt32(&offline, 0)
+
+	t.Run("Sync", func(t *testing.T) {
+		body.Reset()
+		requests, keys = nil, nil
+
+		err := syncAction(ctx, &body, url)
+		if !errors.Is(err, ErrConflict) {
+			t.Errorf("Expected error: %q, but got: %q instead", ErrConflict, err)
+		}
+
+		expectedOutput := "Sent: add \"buy milk\"\n" +
+			"Sent: complete item number 1\n" +
+			"Conflict: delete item number 3: not found: 404 - not found\n" +
+			"Sent 2 operations, 1 conflicts, 0 left\n"
+
+		if expectedOutput != body.String() {
+			t.Errorf("Expected output: %q, but got: %q instead", expectedOutput, body.String())
+		}
+
+		expectedRequests := []string{"POST /todo", "PATCH /todo/1", "DELETE /todo/3", "GET /todo"}
+
+		if !reflect.DeepEqual(expectedRequests, requests) {
+			t.Errorf("Expected requests: %q, but got: %q instead", expectedRequests, requests)
+		}
+
+		if len(keys) != 3 {
+			t.Errorf("Expected an idempotency key for every operation, but got: %q instead", keys)
+		}
+
+		body.Reset()
+
+		if err := syncAction(ctx, &body, url); err != nil {
+			t.Fatalf("Expected no error, but got: %q instead", err)
+		}
+
+		if body.String() != "Nothing to sync\n" {
+			t.Errorf("Expected output: %q, but got: %q instead", "Nothing to sync\n", body.String())
+		}
+	})
+
+	t.Run("ResumeOnNextCommand", func(t *testing.T) {
+		atomic.StoreInt32(&offline, 1)
+
+		if err := addAction(ctx, &body, url, []string{"buy bread"}, addOptions{}); err != nil {
+			t.Fatalf("Expected no error, but got: %q instead", err)
+		}
+
+		atomic.StoreInt32(&offline, 0)
+		requests = nil
+		notices.Reset()
+
+		if err := listAction(ctx, &body, url, textFormatter{}, listOptions{}); err != nil {
+			t.Fatalf("Expected no error, but got: %q instead", err)
+		}
+
+		expectedRequests := []string{"POST /todo", "GET /todo"}
+
+		if !reflect.DeepEqual(expectedRequests, requests) {
+			t.Errorf("Expected requests: %q, but got: %q instead", expectedRequests, requests)
+		}
+
+		if notices.String() != "Sent: add \"buy bread\"\n" {
+			t.Errorf("Expected notices: %q, but got: %q instead", "Sent: add \"buy bread\"\n", notices.String())
+		}
+	})
+}
+
+func TestOfflinePositions(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+
+	retries := viper.Get("retries")
+	viper.Set("retries", 0)
+
+	defer viper.Set("retries", retries)
+
+	stderr = io.Discard
+	defer func() { stderr = os.Stderr }()
+
+	var (
+		mu       sync.Mutex
+		offline  bool
+		requests []string
+	)
+
+	// The server does not send IDs, so items are identified by position.
+	items := []client.Item{{Task: "write report"}, {Task: "call mum"}, {Task: "water plants"}}
+
+	url, cleanup := mockServer(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if offline {
+			conn, _, err := w.(http.Hijacker).Hijack()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			conn.Close()
+
+			return
+		}
+
+		requests = append(requests, r.Method+" "+r.URL.Path)
+
+		position, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/todo/"))
+
+		switch {
+		case r.Method == http.MethodDelete:
+			w.WriteHeader(testResp["noContent"].Status)
+		case err == nil && position <= len(items):
+			json.NewEncoder(w).Encode(client.Response{Results: items[position-1 : position]})
+		case err == nil:
+			w.WriteHeader(testResp["notFound"].Status)
+			w.Write([]byte(testResp["notFound"].Body))
+		default:
+			json.NewEncoder(w).Encode(client.Response{Results: items, TotalResults: len(items)})
+		}
+	})
+
+	defer cleanup()
+
+	setOffline := func(v bool) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		offline = v
+	}
+
+	ctx := context.Background()
+
+	var body bytes.Buffer
+
+	// A successful list is cached.
+	if err := listAction(ctx, io.Discard, url, textFormatter{}, listOptions{}); err != nil {
+		t.Fatalf("Expected no error, but got: %q instead", err)
+	}
+
+	setOffline(true)
+
+	// Positions missing from the cached list cannot be checked when sent,
+	// and are not queued.
+	err := deleteAction(ctx, &body, url, []string{"3,5"})
+	if !errors.Is(err, client.ErrConnection) || !strings.Contains(err.Error(), "position 5 is not cached") {
+		t.Errorf("Expected error: %q, but got: %q instead", client.ErrConnection, err)
+	}
+
+	expectedOutput := "API unreachable, item number 3 queued to be deleted\n" +
+		"Run sync to send the queued operations\n"
+
+	if expectedOutput != body.String() {
+		t.Errorf("Expected output: %q, but got: %q instead", expectedOutput, body.String())
+	}
+
+	// Another client adds an item before the last one, which shifts it.
+	mu.Lock()
+	items = []client.Item{items[0], items[1], {Task: "buy bread"}, items[2]}
+	mu.Unlock()
+
+	setOffline(false)
+
+	body.Reset()
+
+	err = syncAction(ctx, &body, url)
+	if !errors.Is(err, ErrConflict) {
+		t.Errorf("Expected error: %q, but got: %q instead", ErrConflict, err)
+	}
+
+	expectedOutput = "Conflict: delete item number 3: conflicting operations: " +
+		"the item at position 3 is now \"buy bread\", not \"water plants\"\n" +
+		"Sent 0 operations, 1 conflicts, 0 left\n"
+
+	if expectedOutput != body.String() {
+		t.Errorf("Expected output: %q, but got: %q instead", expectedOutput, body.String())
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	for _, r := range requests {
+		if strings.HasPrefix(r, http.MethodDelete) {
+			t.Errorf("Expected no delete to be sent, but got: %q instead", requests)
+		}
+	}
+}
+
+func TestSyncKeepsOperations(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+
+	// Server errors are not retried, to keep the test fast.
+	retries := viper.Get("retries")
+	viper.Set("retries", 0)
+
+	defer viper.Set("retries", retries)
+
+	url, cleanup := mockServer(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte("down for maintenance"))
+	})
+
+	defer cleanup()
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	testCases := []struct {
+		name           string
+		ctx            context.Context
+		expectedErr    error
+		expectedOutput string
+	}{
+		{
+			name:        "ServerError",
+			ctx:         context.Background(),
+			expectedErr: client.ErrInvalidResponse,
+			expectedOutput: "Stopped at complete item number 1: invalid response: down for maintenance, " +
+				"2 queued operations left\n" +
+				"Sent 0 operations, 0 conflicts, 2 left\n",
+		},
+		{
+			name:        "Cancelled",
+			ctx:         cancelled,
+			expectedErr: context.Canceled,
+			expectedOutput: "Stopped at complete item number 1: context canceled, 2 queued operations left\n" +
+				"Sent 0 operations, 0 conflicts, 2 left\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := newClient(url)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			s, err := offlineStoreFor(c)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, id := range []int{1, 2} {
+				op, err := newOperation(opComplete, id, nil)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if err := s.queue(op); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			defer s.setPending(nil)
+
+			var body bytes.Buffer
+
+			err = syncAction(tc.ctx, &body, url)
+			if !errors.Is(err, tc.expectedErr) {
+				t.Errorf("Expected error: %q, but got: %q instead", tc.expectedErr, err)
+			}
+
+			if tc.expectedOutput != body.String() {
+				t.Errorf("Expected output: %q, but got: %q instead", tc.expectedOutput, body.String())
+			}
+
+			if ops, _ := s.pending(); len(ops) != 2 {
+				t.Errorf("Expected 2 queued operations, but got: %d instead", len(ops))
+			}
+		})
+	}
+}
+
+func TestOutboxConcurrentQueue(t *testing.T) {
+	s := &offlineStore{dir: t.TempDir()}
+
+	const n = 100
+
+	var wg sync.WaitGroup
+
+	errs := make(chan error, n)
+
+	// The goroutines stand for commands queueing at the same time.
+	for i := 1; i <= n; i++ {
+		wg.Add(1)
+
+		go func(id int) {
+			defer wg.Done()
+
+			op, err := newOperation(opDelete, id, nil)
+			if err == nil {
+				err = s.queue(op)
+			}
+
+			errs <- err
+		}(i)
+	}
+
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("Expected no error, but got: %q instead", err)
+		}
+	}
+
+	if ops, _ := s.pending(); len(ops) != n {
+		t.Errorf("Expected %d queued operations, but got: %d instead", n, len(ops))
+	}
+}
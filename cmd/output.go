@@ -0,0 +1,506 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/mycok/todo_list_client/client"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"golang.org/x/term"
+	"gopkg.in/yaml.v3"
+)
+
+var ErrInvalidFormat = errors.New("invalid output format")
+
+// record is the representation of a todo item handed to the output
+// formatters. ID is the number other commands use to refer to the item.
+type record struct {
+	ID          int        `json:"id" yaml:"id"`
+	UUID        string     `json:"uuid,omitempty" yaml:"uuid,omitempty"`
+	Task        string     `json:"task" yaml:"task"`
+	Done        bool       `json:"done" yaml:"done"`
+	CreatedAt   time.Time  `json:"created_at" yaml:"created_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty" yaml:"completed_at,omitempty"`
+	Due         *time.Time `json:"due,omitempty" yaml:"due,omitempty"`
+	Priority    string     `json:"priority,omitempty" yaml:"priority,omitempty"`
+	Tags        []string   `json:"tags,omitempty" yaml:"tags,omitempty"`
+	Notes       string     `json:"notes,omitempty" yaml:"notes,omitempty"`
+	Parent      int        `json:"parent,omitempty" yaml:"parent,omitempty"`
+	Recurrence  string     `json:"recurrence,omitempty" yaml:"recurrence,omitempty"`
+
+	// Subtasks are the items whose parent is this one. They are only set
+	// for a single item.
+	Subtasks []record `json:"subtasks,omitempty" yaml:"subtasks,omitempty"`
+}
+
+func newRecord(id int, i client.Item) record {
+	r := record{
+		ID:         id,
+		UUID:       i.UUID,
+		Task:       i.Task,
+		Done:       i.Done,
+		CreatedAt:  i.CreatedAt,
+		Priority:   i.Priority,
+		Tags:       i.Tags,
+		Notes:      i.Notes,
+		Parent:     i.Parent,
+		Recurrence: i.Recurrence,
+	}
+
+	if !i.CompletedAt.IsZero() {
+		completedAt := i.CompletedAt
+		r.CompletedAt = &completedAt
+	}
+
+	if !i.Due.IsZero() {
+		due := i.Due
+		r.Due = &due
+	}
+
+	return r
+}
+
+// taskLabel returns the task of r followed by its priority and tags in the
+// syntax add accepts, e.g. "fix login !high +backend".
+func taskLabel(r record) string {
+	label := r.Task
+
+	if r.Priority != "" {
+		label += " !" + r.Priority
+	}
+
+	for _, tag := range r.Tags {
+		label += " +" + tag
+	}
+
+	return label
+}
+
+// overdue reports whether r is still pending past its due date.
+func overdue(r record, now time.Time) bool {
+	return !r.Done && r.Due != nil && r.Due.Before(now)
+}
+
+// formatDue returns the due date of r for the text output, marked when the
+// item is overdue and highlighted in red if color is set.
+func formatDue(r record, now time.Time, color bool) string {
+	if r.Due == nil {
+		return ""
+	}
+
+	due := r.Due.Local().Format(dueFormat)
+
+	if !overdue(r, now) {
+		return due
+	}
+
+	due += " (overdue)"
+
+	if color {
+		due = "\x1b[31m" + due + "\x1b[0m"
+	}
+
+	return due
+}
+
+// useColor reports whether w is a terminal that text can be highlighted on.
+// Setting NO_COLOR disables highlighting.
+func useColor(w io.Writer) bool {
+	if _, ok := os.LookupEnv("NO_COLOR"); ok {
+		return false
+	}
+
+	f, ok := w.(*os.File)
+
+	return ok && term.IsTerminal(int(f.Fd()))
+}
+
+// newRecords numbers items by the ID the server gave them, or by their
+// position in the list for servers that do not send IDs.
+func newRecords(items []client.Item) []record {
+	records := make([]record, len(items))
+
+	for i, item := range items {
+		records[i] = newRecord(item.Ref(), item)
+	}
+
+	return records
+}
+
+// formatter renders todo items in a specific output format.
+type formatter interface {
+	formatList(w io.Writer, records []record) error
+	formatItem(w io.Writer, r record) error
+}
+
+// streamFormatter is implemented by the formatters that can write a list
+// one record at a time, as the pages of a long list come in.
+type streamFormatter interface {
+	formatter
+	stream(w io.Writer) recordWriter
+}
+
+// recordWriter writes the records of a list one at a time. close finishes
+// the list, and must be called even if no record was written.
+type recordWriter interface {
+	write(r record) error
+	close() error
+}
+
+// formatters holds the output formats selectable with the --output flag.
+var formatters = map[string]formatter{
+	"text": textFormatter{},
+	"json": jsonFormatter{},
+	"yaml": yamlFormatter{},
+	"csv":  delimitedFormatter{comma: ','},
+	"tsv":  delimitedFormatter{comma: '\t'},
+}
+
+func getFormatter(name string) (formatter, error) {
+	f, ok := formatters[name]
+	if !ok {
+		return nil, fmt.Errorf(
+			"%w: %q, must be one of: %s",
+			ErrInvalidFormat, name, strings.Join(formatterNames(), ", "),
+		)
+	}
+
+	return f, nil
+}
+
+// addFormatFlags adds the flags that customise how cmd prints todo items on
+// top of the global --output flag.
+func addFormatFlags(cmd *cobra.Command) {
+	cmd.Flags().String("template", "", "Go template applied to the items, e.g. '{{range .}}{{.ID}} {{.Task}}{{\"\\n\"}}{{end}}'")
+	cmd.Flags().String("jsonpath", "", "JSONPath template applied to the JSON output, as with kubectl, e.g. '{[?(@.done==false)].id}' or '{range [*]}{.id}{\"\\t\"}{.task}{\"\\n\"}{end}'")
+}
+
+func formatterFromFlags(cmd *cobra.Command) (formatter, error) {
+	tmpl, err := cmd.Flags().GetString("template")
+	if err != nil {
+		return nil, err
+	}
+
+	jsonPath, err := cmd.Flags().GetString("jsonpath")
+	if err != nil {
+		return nil, err
+	}
+
+	return resolveFormatter(viper.GetString("output"), tmpl, jsonPath)
+}
+
+// resolveFormatter returns the formatter selected by the output flags. A Go
+// template or a JSONPath expression takes precedence over the named format,
+// and is parsed here so that mistakes are reported before any request.
+func resolveFormatter(output, tmpl, jsonPath string) (formatter, error) {
+	switch {
+	case tmpl != "" && jsonPath != "":
+		return nil, fmt.Errorf(
+			"%w: --template and --jsonpath cannot be used together",
+			ErrInvalidFormat,
+		)
+
+	case tmpl != "":
+		t, err := template.New("output").Parse(tmpl)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidFormat, err)
+		}
+
+		return templateFormatter{t}, nil
+
+	case jsonPath != "":
+		p, err := parseJSONPath(jsonPath)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidFormat, err)
+		}
+
+		return jsonPathFormatter{p}, nil
+	}
+
+	return getFormatter(output)
+}
+
+func formatterNames() []string {
+	names := make([]string, 0, len(formatters))
+
+	for name := range formatters {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// textFormatter prints items for people to read. With markdown set, the
+// notes of an item are rendered as Markdown.
+type textFormatter struct {
+	markdown bool
+}
+
+func (textFormatter) formatList(w io.Writer, records []record) error {
+	return printItems(w, records)
+}
+
+func (f textFormatter) formatItem(w io.Writer, r record) error {
+	return printItem(w, r, f.markdown)
+}
+
+// treeFormatter prints lists as a tree of items and their subtasks, and
+// single items like textFormatter.
+type treeFormatter struct {
+	textFormatter
+}
+
+func (treeFormatter) formatList(w io.Writer, records []record) error {
+	return printTree(w, records)
+}
+
+type jsonFormatter struct{}
+
+func (jsonFormatter) formatList(w io.Writer, records []record) error {
+	return writeJSON(w, records)
+}
+
+func (jsonFormatter) formatItem(w io.Writer, r record) error {
+	return writeJSON(w, r)
+}
+
+func (jsonFormatter) stream(w io.Writer) recordWriter {
+	return &jsonStream{w: w}
+}
+
+// jsonStream writes records as the same indented JSON array as
+// jsonFormatter.formatList.
+type jsonStream struct {
+	w io.Writer
+	n int
+}
+
+func (s *jsonStream) write(r record) error {
+	data, err := json.MarshalIndent(r, "  ", "  ")
+	if err != nil {
+		return err
+	}
+
+	sep := ",\n  "
+	if s.n == 0 {
+		sep = "[\n  "
+	}
+
+	s.n++
+
+	if _, err := io.WriteString(s.w, sep); err != nil {
+		return err
+	}
+
+	_, err = s.w.Write(data)
+
+	return err
+}
+
+func (s *jsonStream) close() error {
+	end := "\n]\n"
+	if s.n == 0 {
+		end = "[]\n"
+	}
+
+	_, err := io.WriteString(s.w, end)
+
+	return err
+}
+
+func writeJSON(w io.Writer, v interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(v)
+}
+
+type yamlFormatter struct{}
+
+func (yamlFormatter) formatList(w io.Writer, records []record) error {
+	return writeYAML(w, records)
+}
+
+func (yamlFormatter) formatItem(w io.Writer, r record) error {
+	return writeYAML(w, r)
+}
+
+func writeYAML(w io.Writer, v interface{}) error {
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+
+	if err := enc.Encode(v); err != nil {
+		return err
+	}
+
+	return enc.Close()
+}
+
+// templateFormatter executes a Go template with the list of records, or a
+// single record, as its data.
+type templateFormatter struct {
+	t *template.Template
+}
+
+func (f templateFormatter) formatList(w io.Writer, records []record) error {
+	return f.t.Execute(w, records)
+}
+
+func (f templateFormatter) formatItem(w io.Writer, r record) error {
+	return f.t.Execute(w, r)
+}
+
+// jsonPathFormatter prints a JSONPath template applied to the JSON
+// representation of the records.
+type jsonPathFormatter struct {
+	p *jsonPath
+}
+
+func (f jsonPathFormatter) formatList(w io.Writer, records []record) error {
+	return f.write(w, records)
+}
+
+func (f jsonPathFormatter) formatItem(w io.Writer, r record) error {
+	return f.write(w, r)
+}
+
+func (f jsonPathFormatter) write(w io.Writer, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	var doc interface{}
+
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+
+	var out bytes.Buffer
+
+	if err := f.p.execute(&out, doc); err != nil {
+		return err
+	}
+
+	// Output that does not end a line gets a newline, for the shell.
+	if out.Len() > 0 && !bytes.HasSuffix(out.Bytes(), []byte("\n")) {
+		out.WriteByte('\n')
+	}
+
+	_, err = w.Write(out.Bytes())
+
+	return err
+}
+
+// delimitedFormatter writes records as CSV, or any other single character
+// delimited format, with a header row.
+type delimitedFormatter struct {
+	comma rune
+}
+
+var delimitedHeader = []string{
+	"id", "task", "done", "created_at", "completed_at", "due", "priority", "tags",
+	"notes", "parent", "recurrence",
+}
+
+func (f delimitedFormatter) formatList(w io.Writer, records []record) error {
+	s := f.stream(w)
+
+	for _, r := range records {
+		if err := s.write(r); err != nil {
+			return err
+		}
+	}
+
+	return s.close()
+}
+
+func (f delimitedFormatter) stream(w io.Writer) recordWriter {
+	cw := csv.NewWriter(w)
+	cw.Comma = f.comma
+
+	return &delimitedStream{cw: cw}
+}
+
+// delimitedStream writes the header row before the first record.
+type delimitedStream struct {
+	cw     *csv.Writer
+	header bool
+}
+
+func (s *delimitedStream) write(r record) error {
+	if err := s.writeHeader(); err != nil {
+		return err
+	}
+
+	return s.cw.Write(delimitedRow(r))
+}
+
+func (s *delimitedStream) close() error {
+	if err := s.writeHeader(); err != nil {
+		return err
+	}
+
+	s.cw.Flush()
+
+	return s.cw.Error()
+}
+
+func (s *delimitedStream) writeHeader() error {
+	if s.header {
+		return nil
+	}
+
+	s.header = true
+
+	return s.cw.Write(delimitedHeader)
+}
+
+func (f delimitedFormatter) formatItem(w io.Writer, r record) error {
+	return f.formatList(w, []record{r})
+}
+
+// delimitedRow formats r as a row of fields. Tags are joined with commas
+// into a single field.
+func delimitedRow(r record) []string {
+	completedAt, due, parent := "", "", ""
+
+	if r.CompletedAt != nil {
+		completedAt = r.CompletedAt.Format(time.RFC3339)
+	}
+
+	if r.Due != nil {
+		due = r.Due.Format(time.RFC3339)
+	}
+
+	if r.Parent != 0 {
+		parent = strconv.Itoa(r.Parent)
+	}
+
+	return []string{
+		strconv.Itoa(r.ID),
+		r.Task,
+		strconv.FormatBool(r.Done),
+		r.CreatedAt.Format(time.RFC3339),
+		completedAt,
+		due,
+		r.Priority,
+		strings.Join(r.Tags, ","),
+		r.Notes,
+		parent,
+		r.Recurrence,
+	}
+}
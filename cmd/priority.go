@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var ErrInvalidPriority = errors.New("invalid priority")
+
+// priorities ranks the accepted priorities, from the most urgent.
+var priorities = map[string]int{
+	"high":   1,
+	"medium": 2,
+	"low":    3,
+}
+
+// parsePriority returns the canonical form of the priority p.
+func parsePriority(p string) (string, error) {
+	p = strings.ToLower(strings.TrimSpace(p))
+
+	if _, ok := priorities[p]; !ok && p != "" {
+		return "", fmt.Errorf(
+			"%w: %q, must be one of: high, medium, low", ErrInvalidPriority, p,
+		)
+	}
+
+	return p, nil
+}
+
+// priorityRank orders priorities from the most urgent, with items without a
+// priority last.
+func priorityRank(p string) int {
+	if rank, ok := priorities[p]; ok {
+		return rank
+	}
+
+	return len(priorities) + 1
+}
+
+// parseTaskText splits the words of a task into its text, the tags given
+// as +tag and the priority given as !high, !medium or !low. Other words
+// starting with + or !, such as a lone "!", are kept in the text.
+func parseTaskText(text string) (string, []string, string) {
+	var (
+		words    []string
+		tags     []string
+		priority string
+	)
+
+	for _, word := range strings.Fields(text) {
+		switch {
+		case len(word) > 1 && strings.HasPrefix(word, "+"):
+			tags = append(tags, word[1:])
+		case strings.HasPrefix(word, "!"):
+			if p, err := parsePriority(word[1:]); err == nil && p != "" {
+				priority = p
+
+				continue
+			}
+
+			words = append(words, word)
+		default:
+			words = append(words, word)
+		}
+	}
+
+	return strings.Join(words, " "), tags, priority
+}
+
+// mergeTags returns the tags in a and b, without the duplicates, ignoring
+// case, and in the order of first appearance.
+func mergeTags(a, b []string) []string {
+	var tags []string
+
+	seen := make(map[string]bool)
+
+	for _, tag := range append(append([]string(nil), a...), b...) {
+		key := strings.ToLower(tag)
+
+		if tag != "" && !seen[key] {
+			seen[key] = true
+			tags = append(tags, tag)
+		}
+	}
+
+	return tags
+}
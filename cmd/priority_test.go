@@ -0,0 +1,78 @@
+//go:build !integration
+// +build !integration
+
+package cmd
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParseTaskText(t *testing.T) {
+	testCases := []struct {
+		name             string
+		text             string
+		expectedTask     string
+		expectedTags     []string
+		expectedPriority string
+	}{
+		{name: "Plain", text: "fix login", expectedTask: "fix login"},
+		{
+			name:             "Inline",
+			text:             "fix +backend login !high +urgent",
+			expectedTask:     "fix login",
+			expectedTags:     []string{"backend", "urgent"},
+			expectedPriority: "high",
+		},
+		{
+			name:             "PriorityCase",
+			text:             "call mum !LOW",
+			expectedTask:     "call mum",
+			expectedPriority: "low",
+		},
+		{
+			name:         "NotMarkers",
+			text:         "1 + 1 !important wow !",
+			expectedTask: "1 + 1 !important wow !",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			task, tags, priority := parseTaskText(tc.text)
+
+			if tc.expectedTask != task {
+				t.Errorf("Expected task: %q, but got: %q instead", tc.expectedTask, task)
+			}
+
+			if !reflect.DeepEqual(tc.expectedTags, tags) {
+				t.Errorf("Expected tags: %q, but got: %q instead", tc.expectedTags, tags)
+			}
+
+			if tc.expectedPriority != priority {
+				t.Errorf("Expected priority: %q, but got: %q instead", tc.expectedPriority, priority)
+			}
+		})
+	}
+}
+
+func TestParsePriority(t *testing.T) {
+	if p, err := parsePriority(" Medium "); err != nil || p != "medium" {
+		t.Errorf("Expected priority: medium, but got: %q, %v instead", p, err)
+	}
+
+	if _, err := parsePriority("urgent"); !errors.Is(err, ErrInvalidPriority) {
+		t.Errorf("Expected error: %q, but got: %q instead", ErrInvalidPriority, err)
+	}
+}
+
+func TestMergeTags(t *testing.T) {
+	tags := mergeTags([]string{"backend", "Urgent"}, []string{"urgent", "api", ""})
+
+	expected := []string{"backend", "Urgent", "api"}
+
+	if !reflect.DeepEqual(expected, tags) {
+		t.Errorf("Expected tags: %q, but got: %q instead", expected, tags)
+	}
+}
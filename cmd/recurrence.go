@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/mycok/todo_list_client/client"
+	"github.com/mycok/todo_list_client/recur"
+)
+
+var ErrInvalidRecurrence = errors.New("invalid recurrence")
+
+// parseRecurrence parses the schedule given to --every.
+func parseRecurrence(every string) (recur.Rule, error) {
+	rule, err := recur.Parse(every)
+	if err != nil {
+		return recur.Rule{}, fmt.Errorf("%w: %s", ErrInvalidRecurrence, err)
+	}
+
+	return rule, nil
+}
+
+// nextOccurrence returns the due date of the occurrence of rule after the
+// one due on due, skipping the occurrences already past at now. It returns
+// the zero time when the rule has no occurrence left.
+func nextOccurrence(rule recur.Rule, due, now time.Time) time.Time {
+	if due.IsZero() {
+		due = now
+	}
+
+	next := rule.Next(due)
+
+	for !next.IsZero() && next.Before(now) {
+		next = rule.Next(next)
+	}
+
+	return next
+}
+
+// nextDates returns the due dates of the next n occurrences of rule after
+// the one due on due.
+func nextDates(rule recur.Rule, due, now time.Time, n int) []time.Time {
+	next := nextOccurrence(rule, due, now)
+	if next.IsZero() {
+		return nil
+	}
+
+	return append([]time.Time{next}, rule.NextN(next, n-1)...)
+}
+
+// addNextOccurrence adds the next occurrence of item, a recurring item that
+// was just completed, as a new item with the same details.
+func addNextOccurrence(
+	ctx context.Context, w io.Writer, c *client.Client, item client.Item, now time.Time,
+) error {
+	rule, err := parseRecurrence(item.Recurrence)
+	if err != nil {
+		return fmt.Errorf("item number %d: %w", item.Ref(), err)
+	}
+
+	next := nextOccurrence(rule, item.Due, now)
+	if next.IsZero() {
+		_, err := fmt.Fprintf(w, "Item number %d has no more occurrences\n", item.Ref())
+
+		return err
+	}
+
+	newItem := client.NewItem{
+		Task:       item.Task,
+		Due:        &next,
+		Priority:   item.Priority,
+		Tags:       item.Tags,
+		Notes:      item.Notes,
+		Parent:     item.Parent,
+		Recurrence: item.Recurrence,
+	}
+
+	op, err := newOperation(opAdd, 0, &newItem)
+	if err != nil {
+		return err
+	}
+
+	queued, err := sendOrQueue(ctx, c, op)
+	if err != nil {
+		return err
+	}
+
+	status := "added"
+	if queued {
+		status = "queued"
+	}
+
+	_, err = fmt.Fprintf(
+		w, "Next occurrence of item number %d %s, due on %s\n",
+		item.Ref(), status, next.Local().Format(dueFormat),
+	)
+
+	return err
+}
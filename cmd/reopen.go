@@ -0,0 +1,86 @@
+/*
+Copyright © 2022 mycok <github.com/mycok>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// reopenCmd represents the reopen command
+var reopenCmd = &cobra.Command{
+	Use:     "reopen <itemID>...",
+	Aliases: []string{"uncomplete"},
+	Short:   "Mark one or more completed todo items as pending again",
+	Long: `Mark one or more completed todo items as pending again, clearing their
+completion date.
+
+Items are given like for the complete command, e.g. "reopen 3 5 7-12" or
+"reopen #2". Servers that cannot reopen items report an error saying so.`,
+	Args:         cobra.MinimumNArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		rootURL := viper.GetString("api-root")
+
+		return reopenAction(cmd.Context(), os.Stdout, rootURL, args)
+	},
+}
+
+func reopenAction(
+	ctx context.Context, w io.Writer, url string, args []string,
+) error {
+	refs, err := parseIDs(args)
+	if err != nil {
+		return err
+	}
+
+	c, err := newClient(url)
+	if err != nil {
+		return err
+	}
+
+	resumeOutbox(ctx, c)
+
+	ids, err := resolveIDs(ctx, c, refs)
+	if err != nil {
+		return err
+	}
+
+	results, err := c.ReopenMany(ctx, ids, viper.GetInt("parallel"))
+
+	return printResults(w, results, err, "reopened", printReopenedItem)
+}
+
+func printReopenedItem(w io.Writer, id int) error {
+	_, err := fmt.Fprintf(w, "Item number %d marked as pending\n", id)
+
+	return err
+}
+
+func init() {
+	rootCmd.AddCommand(reopenCmd)
+}
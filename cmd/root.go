@@ -22,9 +22,14 @@ THE SOFTWARE.
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
+	"os/signal"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -45,7 +50,11 @@ var rootCmd = &cobra.Command{
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
-	err := rootCmd.Execute()
+	// Cancel any in-flight request when the user interrupts the command.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	err := rootCmd.ExecuteContext(ctx)
 	if err != nil {
 		os.Exit(1)
 	}
@@ -54,13 +63,41 @@ func Execute() {
 func init() {
 	cobra.OnInitialize(initConfig)
 
-	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.todo_list_client.yaml)")
+	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $XDG_CONFIG_HOME/todo_list_client/config.yaml, then $HOME/.todo_list_client.yaml)")
+	rootCmd.PersistentFlags().String("profile", "", "Named profile from the config file to use")
 	rootCmd.PersistentFlags().String("api-root", "http://localhost:8080", "Todo List API URL")
+	rootCmd.PersistentFlags().String("list", "", "Named list to work on instead of the default one")
+	rootCmd.PersistentFlags().String("token-file", "", "File holding the bearer token to authenticate with")
+	rootCmd.PersistentFlags().String("ca-cert", "", "PEM file of the certificate authorities to trust for the API")
+	rootCmd.PersistentFlags().String("client-cert", "", "PEM client certificate for mutual TLS")
+	rootCmd.PersistentFlags().String("client-key", "", "PEM client key for mutual TLS")
+	rootCmd.PersistentFlags().String("tls-server-name", "", "Server name to verify the API certificate against")
+	rootCmd.PersistentFlags().Bool("insecure-skip-verify", false, "Do not verify the API certificate (insecure, for testing only)")
+	rootCmd.PersistentFlags().StringP("output", "o", "text", "Output format: text, json, yaml, csv or tsv")
+	rootCmd.PersistentFlags().Int("parallel", 4, "Maximum number of concurrent requests in bulk operations")
+	rootCmd.PersistentFlags().Duration("timeout", 10*time.Second, "Time limit for each API request, including retries (0 means no limit)")
+	rootCmd.PersistentFlags().Int("retries", 2, "Number of times to retry a request that failed with a transient error")
+	rootCmd.PersistentFlags().Duration("retry-max-wait", 30*time.Second, "Longest wait between two attempts of a request")
+	rootCmd.PersistentFlags().Bool("no-cache", false, "Do not use or update the cache of API responses")
 
 	replacer := strings.NewReplacer("-", "_")
 	viper.SetEnvKeyReplacer(replacer)
 	viper.SetEnvPrefix("TODO")
+	viper.BindPFlag("profile", rootCmd.PersistentFlags().Lookup("profile"))
 	viper.BindPFlag("api-root", rootCmd.PersistentFlags().Lookup("api-root"))
+	viper.BindPFlag("list", rootCmd.PersistentFlags().Lookup("list"))
+	viper.BindPFlag("token-file", rootCmd.PersistentFlags().Lookup("token-file"))
+	viper.BindPFlag("ca-cert", rootCmd.PersistentFlags().Lookup("ca-cert"))
+	viper.BindPFlag("client-cert", rootCmd.PersistentFlags().Lookup("client-cert"))
+	viper.BindPFlag("client-key", rootCmd.PersistentFlags().Lookup("client-key"))
+	viper.BindPFlag("tls-server-name", rootCmd.PersistentFlags().Lookup("tls-server-name"))
+	viper.BindPFlag("insecure-skip-verify", rootCmd.PersistentFlags().Lookup("insecure-skip-verify"))
+	viper.BindPFlag("output", rootCmd.PersistentFlags().Lookup("output"))
+	viper.BindPFlag("parallel", rootCmd.PersistentFlags().Lookup("parallel"))
+	viper.BindPFlag("timeout", rootCmd.PersistentFlags().Lookup("timeout"))
+	viper.BindPFlag("retries", rootCmd.PersistentFlags().Lookup("retries"))
+	viper.BindPFlag("retry-max-wait", rootCmd.PersistentFlags().Lookup("retry-max-wait"))
+	viper.BindPFlag("no-cache", rootCmd.PersistentFlags().Lookup("no-cache"))
 
 	// Cobra also supports local flags, which will only run
 	// when this action is called directly.
@@ -75,19 +112,43 @@ func initConfig() {
 		// Use config file from the flag.
 		viper.SetConfigFile(cfgFile)
 	} else {
-		// Find home directory.
-		home, err := os.UserHomeDir()
+		path, err := findConfigFile()
 		cobra.CheckErr(err)
 
-		// Search config in home directory with name ".cobra" (without extension).
-		viper.AddConfigPath(home)
-		viper.SetConfigType("yaml")
-		viper.SetConfigName(".cobra")
+		if path != "" {
+			viper.SetConfigFile(path)
+		}
 	}
 
 	viper.AutomaticEnv()
 
-	if err := viper.ReadInConfig(); err == nil {
-		fmt.Println("Using config file:", viper.ConfigFileUsed())
+	// Diagnostics go to stderr so that they do not mix with the output of
+	// the command, which may be piped to another program.
+	if viper.ConfigFileUsed() != "" {
+		switch err := viper.ReadInConfig(); {
+		case errors.Is(err, fs.ErrNotExist):
+			// A --config file that does not exist yet is created by the
+			// commands that edit the configuration.
+		case err != nil:
+			fmt.Fprintln(os.Stderr, "Warning: reading config file:", err)
+		default:
+			fmt.Fprintln(os.Stderr, "Using config file:", viper.ConfigFileUsed())
+		}
+	}
+
+	// Resolve the profile in use before any command reads its settings.
+	if viper.ConfigFileUsed() != "" {
+		err := applyProfile(viper.ConfigFileUsed())
+
+		// A profile selected in the config file may have been removed by
+		// hand. Only a profile asked for with --profile must exist.
+		if errors.Is(err, ErrProfileNotFound) && viper.GetString("profile") == "" {
+			fmt.Fprintln(os.Stderr, "Warning:", err)
+			err = nil
+		}
+
+		cobra.CheckErr(err)
+	} else if name := viper.GetString("profile"); name != "" {
+		cobra.CheckErr(fmt.Errorf("%w: %q, no config file found", ErrProfileNotFound, name))
 	}
 }
@@ -0,0 +1,277 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/mycok/todo_list_client/client"
+)
+
+// subtasksOf maps the ID of every parent in items to its subtasks, in the
+// order of items.
+func subtasksOf(items []client.Item) map[int][]client.Item {
+	subtasks := make(map[int][]client.Item)
+
+	for _, i := range items {
+		if i.Parent != 0 {
+			subtasks[i.Parent] = append(subtasks[i.Parent], i)
+		}
+	}
+
+	return subtasks
+}
+
+// findSubtasks returns the direct subtasks of the item identified by id,
+// asking the server for them by parent.
+func findSubtasks(
+	ctx context.Context, c *client.Client, id int,
+) ([]client.Item, error) {
+	subtasks, err := c.Find(ctx, client.Query{Parent: id})
+	if errors.Is(err, client.ErrNotFound) {
+		return nil, nil
+	}
+
+	return subtasks, err
+}
+
+// lookupItems fetches the items identified by ids, keyed by ID, along with
+// their subtasks at any depth, without listing the whole collection. Items
+// the server does not know are left out, for the request made with them to
+// report. When the API is unreachable, both are taken from the cached list.
+func lookupItems(
+	ctx context.Context, c *client.Client, ids []int,
+) (map[int]client.Item, map[int][]client.Item, error) {
+	items := make(map[int]client.Item, len(ids))
+	subtasks := make(map[int][]client.Item)
+
+	for _, id := range ids {
+		// Servers without IDs have no subtasks, which point to the ID of
+		// their parent.
+		item, err := c.Get(ctx, id)
+		if err == nil && item.ID != 0 {
+			err = addSubtasks(ctx, c, subtasks, id)
+		}
+
+		switch {
+		case errors.Is(err, client.ErrConnection):
+			cached, _ := cachedItems(c, err)
+
+			return byRef(cached), subtasksOf(cached), nil
+		case errors.Is(err, client.ErrNotFound):
+			continue
+		case err != nil:
+			return nil, nil, err
+		}
+
+		items[id] = item
+	}
+
+	return items, subtasks, nil
+}
+
+// addSubtasks adds the subtasks of id, and theirs in turn, to subtasks,
+// asking the server for the subtasks of every item by parent.
+func addSubtasks(
+	ctx context.Context, c *client.Client, subtasks map[int][]client.Item, id int,
+) error {
+	// Items already looked up, wrongly their own ancestor or not, are
+	// skipped.
+	if _, ok := subtasks[id]; ok {
+		return nil
+	}
+
+	found, err := findSubtasks(ctx, c, id)
+	if err != nil {
+		return err
+	}
+
+	subtasks[id] = found
+
+	for _, s := range found {
+		if err := addSubtasks(ctx, c, subtasks, s.Ref()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// byRef maps the number that identifies every item in items to the item.
+func byRef(items []client.Item) map[int]client.Item {
+	refs := make(map[int]client.Item, len(items))
+
+	for _, i := range items {
+		refs[i.Ref()] = i
+	}
+
+	return refs
+}
+
+// openSubtasks returns the IDs of the pending subtasks of id, at any depth,
+// with the subtasks of an item before the item itself.
+func openSubtasks(subtasks map[int][]client.Item, id int) []int {
+	var (
+		ids  []int
+		walk func(id int)
+	)
+
+	// seen guards against items that are, wrongly, their own ancestor.
+	seen := map[int]bool{id: true}
+
+	walk = func(id int) {
+		for _, s := range subtasks[id] {
+			if seen[s.Ref()] {
+				continue
+			}
+
+			seen[s.Ref()] = true
+
+			walk(s.Ref())
+
+			if !s.Done {
+				ids = append(ids, s.Ref())
+			}
+		}
+	}
+
+	walk(id)
+
+	return ids
+}
+
+// progress returns the number of done records and the total.
+func progress(records []record) (int, int) {
+	done := 0
+
+	for _, r := range records {
+		if r.Done {
+			done++
+		}
+	}
+
+	return done, len(records)
+}
+
+// printSubtasks writes the subtasks of an item as a checklist under a
+// heading with the progress made.
+func printSubtasks(w io.Writer, subtasks []record) error {
+	if len(subtasks) == 0 {
+		return nil
+	}
+
+	done, total := progress(subtasks)
+
+	if _, err := fmt.Fprintf(w, "\nSubtasks (%d/%d done):\n", done, total); err != nil {
+		return err
+	}
+
+	for _, s := range subtasks {
+		check := " "
+		if s.Done {
+			check = "x"
+		}
+
+		if _, err := fmt.Fprintf(w, "  [%s] %d %s\n", check, s.ID, taskLabel(s)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// printTree writes records as a tree of items and their subtasks, drawn
+// with box-drawing characters. Items whose parent is not among records are
+// shown at the top level.
+func printTree(w io.Writer, records []record) error {
+	ids := make(map[int]bool, len(records))
+
+	for _, r := range records {
+		ids[r.ID] = true
+	}
+
+	children := make(map[int][]record)
+
+	var roots []record
+
+	for _, r := range records {
+		if r.Parent != 0 && r.Parent != r.ID && ids[r.Parent] {
+			children[r.Parent] = append(children[r.Parent], r)
+
+			continue
+		}
+
+		roots = append(roots, r)
+	}
+
+	color := useColor(w)
+	now := time.Now()
+	printed := make(map[int]bool, len(records))
+
+	var printNode func(r record, branch, indent string) error
+
+	printNode = func(r record, branch, indent string) error {
+		printed[r.ID] = true
+
+		done := "𝘅"
+		if r.Done {
+			done = "✅"
+		}
+
+		var line strings.Builder
+
+		fmt.Fprintf(&line, "%s%s %d %s", branch, done, r.ID, taskLabel(r))
+
+		if len(children[r.ID]) > 0 {
+			d, total := progress(children[r.ID])
+			fmt.Fprintf(&line, " (%d/%d done)", d, total)
+		}
+
+		if due := formatDue(r, now, color); due != "" {
+			line.WriteString("  " + due)
+		}
+
+		if _, err := fmt.Fprintln(w, line.String()); err != nil {
+			return err
+		}
+
+		kids := children[r.ID]
+
+		for i, child := range kids {
+			if printed[child.ID] {
+				continue
+			}
+
+			branch, next := "├── ", "│   "
+			if i == len(kids)-1 {
+				branch, next = "└── ", "    "
+			}
+
+			if err := printNode(child, indent+branch, indent+next); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	for _, r := range roots {
+		if err := printNode(r, "", ""); err != nil {
+			return err
+		}
+	}
+
+	// Items in a cycle of parents have no root to be printed under.
+	for _, r := range records {
+		if !printed[r.ID] {
+			if err := printNode(r, "", ""); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,112 @@
+/*
+Copyright © 2022 mycok <github.com/mycok>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// syncCmd represents the sync command
+var syncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Send the operations queued while the API was unreachable",
+	Long: `Send the operations queued while the API was unreachable.
+
+The add, complete and del commands queue their changes in an outbox when
+the API cannot be reached, and list and view show the items cached by the
+last successful request instead. The outbox is kept per API and list in
+$XDG_STATE_HOME/todo_list_client (~/.local/state/todo_list_client by
+default).
+
+Queued operations are sent in the order they were made, by this command or
+by the next command that reaches the API. Operations that conflict with
+the state of the server, such as completing an item deleted in the
+meantime, are reported as conflicts and dropped from the outbox. Any other
+failure, such as a server error or an interrupted sync, stops the sync and
+keeps the operations left in the outbox.
+
+On servers that identify items by their position, an item is only queued
+when the cached list holds its position, and the operation is a conflict
+if the item at that position has another task by the time it is sent.`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		rootURL := viper.GetString("api-root")
+
+		return syncAction(cmd.Context(), os.Stdout, rootURL)
+	},
+}
+
+func syncAction(ctx context.Context, w io.Writer, url string) error {
+	c, err := newClient(url)
+	if err != nil {
+		return err
+	}
+
+	s, err := offlineStoreFor(c)
+	if err != nil {
+		return err
+	}
+
+	ops, err := s.pending()
+	if err != nil {
+		return err
+	}
+
+	if len(ops) == 0 {
+		_, err := fmt.Fprintln(w, "Nothing to sync")
+
+		return err
+	}
+
+	sent, conflicts, left, syncErr := syncOutbox(ctx, w, c, s)
+
+	if _, err := fmt.Fprintf(
+		w, "Sent %d operations, %d conflicts, %d left\n", sent, conflicts, left,
+	); err != nil {
+		return err
+	}
+
+	if syncErr != nil {
+		return syncErr
+	}
+
+	// Refresh the cache, so that read commands show the synced items if the
+	// API becomes unreachable again.
+	listAll(ctx, c)
+
+	if conflicts > 0 {
+		return fmt.Errorf("%w: %d of %d operations", ErrConflict, conflicts, len(ops))
+	}
+
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(syncCmd)
+}
@@ -22,61 +22,224 @@ THE SOFTWARE.
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
-	"strconv"
+	"strings"
 	"text/tabwriter"
+	"time"
 
+	"github.com/mycok/todo_list_client/client"
+	"github.com/mycok/todo_list_client/recur"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
 
 // viewCmd represents the view command
 var viewCmd = &cobra.Command{
-	Use:          "view <itemID>",
-	Short:        "View a specific todo item with details",
+	Use:   "view <itemID>",
+	Short: "View a specific todo item with details",
+	Long: `View a specific todo item with details.
+
+The item is given by its ID, or by its position in the list with #N. Its
+subtasks are shown as a checklist with the progress made, and the schedule
+of a recurring item with its next three dates.`,
 	SilenceUsage: true,
 	Args:         cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		rootURL := viper.GetString("api-root")
 
-		return viewAction(os.Stdout, rootURL, args[0])
+		f, err := formatterFromFlags(cmd)
+		if err != nil {
+			return err
+		}
+
+		if markdown, _ := cmd.Flags().GetBool("markdown"); markdown {
+			if _, ok := f.(textFormatter); ok {
+				f = textFormatter{markdown: true}
+			}
+		}
+
+		return viewAction(cmd.Context(), os.Stdout, rootURL, args[0], f)
 	},
 }
 
-func viewAction(w io.Writer, url, id string) error {
-	itemID, err := strconv.Atoi(id)
+func viewAction(
+	ctx context.Context, w io.Writer, url, id string, f formatter,
+) error {
+	refs, err := parseIDs([]string{id})
+	if err != nil {
+		return err
+	}
+
+	if len(refs) != 1 {
+		return fmt.Errorf("%w: view takes a single item", ErrInvalidID)
+	}
+
+	c, err := newClient(url)
+	if err != nil {
+		return err
+	}
+
+	resumeOutbox(ctx, c)
+
+	var (
+		items    []client.Item
+		subtasks []client.Item
+	)
+
+	// Positions are looked up in the full list, which also holds the
+	// subtasks of the item.
+	if hasPositions(refs) {
+		if items, err = listAll(ctx, c); err != nil {
+			return err
+		}
+	}
+
+	ids, err := resolveRefs(items, refs)
 	if err != nil {
-		return fmt.Errorf("%w: item ID must me a number", ErrNotNumber)
+		return err
+	}
+
+	item, err := c.Get(ctx, ids[0])
+
+	switch {
+	case err == nil && items != nil:
+		subtasks = subtasksOf(items)[ids[0]]
+	case err == nil && item.ID != 0:
+		subtasks, err = findSubtasks(ctx, c, ids[0])
+	}
+
+	if errors.Is(err, client.ErrConnection) {
+		if items == nil {
+			items, _ = cachedItems(c, err)
+		}
+
+		item, err = findItem(items, ids[0], err)
+		subtasks = subtasksOf(items)[ids[0]]
 	}
 
-	item, err := getItem(url, itemID)
 	if err != nil {
 		return err
 	}
 
-	return printItem(w, item)
+	r := newRecord(ids[0], item)
+
+	if len(subtasks) > 0 {
+		r.Subtasks = newRecords(subtasks)
+	}
+
+	return f.formatItem(w, r)
 }
 
-func printItem(w io.Writer, i item) error {
+func printItem(w io.Writer, r record, markdown bool) error {
 	tw := tabwriter.NewWriter(w, 14, 2, 0, ' ', 0)
 
-	fmt.Fprintf(tw, "Task:\t%s\n", i.Task)
-	fmt.Fprintf(tw, "Created at:\t%s\n", i.CreatedAt.Format(timeFormat))
+	fmt.Fprintf(tw, "Task:\t%s\n", r.Task)
+	fmt.Fprintf(tw, "Created at:\t%s\n", r.CreatedAt.Format(timeFormat))
+
+	if r.Priority != "" {
+		fmt.Fprintf(tw, "Priority:\t%s\n", r.Priority)
+	}
+
+	if len(r.Tags) > 0 {
+		fmt.Fprintf(tw, "Tags:\t%s\n", strings.Join(r.Tags, ", "))
+	}
+
+	if r.Due != nil {
+		fmt.Fprintf(tw, "Due:\t%s\n", formatDue(r, time.Now(), useColor(w)))
+	}
+
+	if r.Done {
+		var completedAt time.Time
+
+		if r.CompletedAt != nil {
+			completedAt = *r.CompletedAt
+		}
 
-	if i.Done {
 		fmt.Fprintf(tw, "Completed:\t%s\n", "Yes")
-		fmt.Fprintf(tw, "CompletedAt:\t%s\n", i.CompletedAt.Format(timeFormat))
+		fmt.Fprintf(tw, "CompletedAt:\t%s\n", completedAt.Format(timeFormat))
+	} else {
+		fmt.Fprintf(tw, "Completed:\t%s\n", "No")
+	}
+
+	if r.Parent != 0 {
+		fmt.Fprintf(tw, "Subtask of:\t%d\n", r.Parent)
+	}
+
+	if r.Recurrence != "" {
+		fmt.Fprintf(tw, "Repeats:\t%s\n", r.Recurrence)
+
+		// Items with a schedule this client cannot read only show it.
+		if rule, err := recur.Parse(r.Recurrence); err == nil {
+			var due time.Time
 
-		return tw.Flush()
+			if r.Due != nil {
+				due = *r.Due
+			}
+
+			dates := nextDates(rule, due, time.Now(), 3)
+			formatted := make([]string, len(dates))
+
+			for i, d := range dates {
+				formatted[i] = d.Local().Format(dueFormat)
+			}
+
+			if len(dates) > 0 {
+				fmt.Fprintf(tw, "Next dates:\t%s\n", strings.Join(formatted, ", "))
+			}
+		}
+	}
+
+	if err := tw.Flush(); err != nil {
+		return err
 	}
 
-	fmt.Fprintf(tw, "Completed:\t%s\n", "No")
+	if err := printSubtasks(w, r.Subtasks); err != nil {
+		return err
+	}
 
-	return tw.Flush()
+	return printNotes(w, r.Notes, markdown)
+}
+
+// printNotes writes notes under a heading, indented and wrapped to the width
+// of w.
+func printNotes(w io.Writer, notes string, markdown bool) error {
+	if notes == "" {
+		return nil
+	}
+
+	const indent = "  "
+
+	width := terminalWidth(w) - len(indent)
+
+	lines := wrapText(notes, width)
+	if markdown {
+		lines = renderMarkdown(notes, width, useColor(w))
+	}
+
+	if _, err := fmt.Fprint(w, "\nNotes:\n"); err != nil {
+		return err
+	}
+
+	for _, line := range lines {
+		if line != "" {
+			line = indent + line
+		}
+
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
 func init() {
 	rootCmd.AddCommand(viewCmd)
+
+	addFormatFlags(viewCmd)
+	viewCmd.Flags().Bool("markdown", false, "Render the notes of the item as Markdown")
 }
@@ -0,0 +1,232 @@
+// Package dateparse parses the dates people type on the command line, such
+// as "tomorrow", "in 3 days", "next friday 17:00" or "2024-03-01", relative
+// to a reference time and in its time zone.
+package dateparse
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidDate = errors.New("invalid date")
+
+// layouts are the absolute date formats accepted, tried in order.
+var layouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// clockLayouts are the accepted formats for a time of day.
+var clockLayouts = []string{
+	"15:04",
+	"3pm",
+	"3:04pm",
+}
+
+var weekdays = map[string]time.Weekday{
+	"sunday":    time.Sunday,
+	"monday":    time.Monday,
+	"tuesday":   time.Tuesday,
+	"wednesday": time.Wednesday,
+	"thursday":  time.Thursday,
+	"friday":    time.Friday,
+	"saturday":  time.Saturday,
+	"sun":       time.Sunday,
+	"mon":       time.Monday,
+	"tue":       time.Tuesday,
+	"wed":       time.Wednesday,
+	"thu":       time.Thursday,
+	"fri":       time.Friday,
+	"sat":       time.Saturday,
+}
+
+// Parse returns the time described by value, relative to now and in the
+// time zone of now unless value carries its own. It accepts:
+//
+//   - ISO dates and times: 2024-03-01, 2024-03-01 17:00, RFC 3339
+//   - now, today, tomorrow and yesterday
+//   - weekday names, optionally prefixed with this or next: "friday" and
+//     "next friday" are the first Friday after today, "this friday" may be
+//     today
+//   - in N minutes, hours, days, weeks or months
+//
+// Any date but "now" and "in N minutes/hours" can be followed by a time of
+// day, such as 17:00, 5pm or "at 5:30pm". A time of day on its own is today.
+// Dates without a time of day are at midnight.
+func Parse(value string, now time.Time) (time.Time, error) {
+	return parse(value, now, false)
+}
+
+// ParseDeadline is like Parse, but dates without a time of day are at the
+// end of that day, so that "today" is not already past.
+func ParseDeadline(value string, now time.Time) (time.Time, error) {
+	return parse(value, now, true)
+}
+
+func parse(value string, now time.Time, endOfDay bool) (time.Time, error) {
+	value = strings.TrimSpace(value)
+
+	for _, layout := range layouts {
+		if t, err := time.ParseInLocation(layout, value, now.Location()); err == nil {
+			if layout == "2006-01-02" && endOfDay {
+				t = atEndOfDay(t)
+			}
+
+			return t, nil
+		}
+	}
+
+	words := strings.Fields(strings.ToLower(value))
+	if len(words) == 0 {
+		return time.Time{}, fmt.Errorf("%w: empty date", ErrInvalidDate)
+	}
+
+	day, rest, exact, err := parseDay(words, now)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: %q: %s", ErrInvalidDate, value, err)
+	}
+
+	if len(rest) > 0 && rest[0] == "at" && !exact {
+		rest = rest[1:]
+	}
+
+	switch {
+	case exact && len(rest) == 0:
+		return day, nil
+
+	case exact:
+		// Relative times down to the minute cannot take a time of day.
+
+	case len(rest) == 0:
+		if endOfDay {
+			return atEndOfDay(day), nil
+		}
+
+		return day, nil
+
+	case len(rest) == 1:
+		hour, min, ok := parseClock(rest[0])
+		if !ok {
+			break
+		}
+
+		return time.Date(
+			day.Year(), day.Month(), day.Day(), hour, min, 0, 0, day.Location(),
+		), nil
+	}
+
+	return time.Time{}, fmt.Errorf(
+		"%w: %q: unexpected %q", ErrInvalidDate, value, strings.Join(rest, " "),
+	)
+}
+
+// parseDay parses the date at the start of words and returns it at
+// midnight, with the words left for the time of day. Relative times down to
+// the minute, such as now, are exact and returned as they are.
+func parseDay(
+	words []string, now time.Time,
+) (day time.Time, rest []string, exact bool, err error) {
+	today := midnight(now)
+
+	switch w := words[0]; w {
+	case "now":
+		return now, words[1:], true, nil
+	case "today":
+		return today, words[1:], false, nil
+	case "tomorrow":
+		return today.AddDate(0, 0, 1), words[1:], false, nil
+	case "yesterday":
+		return today.AddDate(0, 0, -1), words[1:], false, nil
+	case "in":
+		return parseOffset(words[1:], now)
+	case "this", "next":
+		if len(words) < 2 {
+			break
+		}
+
+		if d, ok := weekdays[words[1]]; ok {
+			return nextWeekday(today, d, w == "this"), words[2:], false, nil
+		}
+
+		if w == "next" && words[1] == "week" {
+			return today.AddDate(0, 0, 7), words[2:], false, nil
+		}
+	default:
+		if d, ok := weekdays[w]; ok {
+			return nextWeekday(today, d, false), words[1:], false, nil
+		}
+
+		if _, _, ok := parseClock(w); ok {
+			return today, words, false, nil
+		}
+	}
+
+	return time.Time{}, nil, false, fmt.Errorf("unknown date %q", words[0])
+}
+
+// parseOffset parses the N unit part of "in N unit".
+func parseOffset(
+	words []string, now time.Time,
+) (day time.Time, rest []string, exact bool, err error) {
+	if len(words) < 2 {
+		return time.Time{}, nil, false, errors.New("expected a number and a unit after in")
+	}
+
+	n, err := strconv.Atoi(words[0])
+	if err != nil || n < 0 {
+		return time.Time{}, nil, false, fmt.Errorf("%q is not a number", words[0])
+	}
+
+	rest = words[2:]
+
+	switch strings.TrimSuffix(words[1], "s") {
+	case "minute", "min":
+		return now.Add(time.Duration(n) * time.Minute), rest, true, nil
+	case "hour":
+		return now.Add(time.Duration(n) * time.Hour), rest, true, nil
+	case "day":
+		return midnight(now).AddDate(0, 0, n), rest, false, nil
+	case "week":
+		return midnight(now).AddDate(0, 0, 7*n), rest, false, nil
+	case "month":
+		return midnight(now).AddDate(0, n, 0), rest, false, nil
+	}
+
+	return time.Time{}, nil, false, fmt.Errorf("unknown unit %q", words[1])
+}
+
+// parseClock parses a time of day such as 17:00, 5pm or 5:30pm.
+func parseClock(s string) (int, int, bool) {
+	for _, layout := range clockLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t.Hour(), t.Minute(), true
+		}
+	}
+
+	return 0, 0, false
+}
+
+// nextWeekday returns the first day after today that falls on d, or today
+// itself if it does and includeToday is set.
+func nextWeekday(today time.Time, d time.Weekday, includeToday bool) time.Time {
+	days := (int(d) - int(today.Weekday()) + 7) % 7
+
+	if days == 0 && !includeToday {
+		days = 7
+	}
+
+	return today.AddDate(0, 0, days)
+}
+
+func midnight(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+func atEndOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 23, 59, 59, 0, t.Location())
+}
@@ -0,0 +1,95 @@
+package dateparse
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	zone := time.FixedZone("UTC-5", -5*60*60)
+
+	// A Wednesday.
+	now := time.Date(2024, time.March, 6, 10, 30, 0, 0, zone)
+
+	date := func(month time.Month, day, hour, min, sec int) time.Time {
+		return time.Date(2024, month, day, hour, min, sec, 0, zone)
+	}
+
+	testCases := []struct {
+		value            string
+		expected         time.Time
+		expectedDeadline time.Time
+		expectedErr      error
+	}{
+		{value: "2024-03-01", expected: date(3, 1, 0, 0, 0), expectedDeadline: date(3, 1, 23, 59, 59)},
+		{value: "2024-03-01 17:00", expected: date(3, 1, 17, 0, 0)},
+		{value: "2024-03-01T17:00", expected: date(3, 1, 17, 0, 0)},
+		{
+			value:    "2024-03-01T17:00:00Z",
+			expected: time.Date(2024, time.March, 1, 17, 0, 0, 0, time.UTC),
+		},
+		{value: "now", expected: now},
+		{value: "today", expected: date(3, 6, 0, 0, 0), expectedDeadline: date(3, 6, 23, 59, 59)},
+		{value: "Tomorrow", expected: date(3, 7, 0, 0, 0), expectedDeadline: date(3, 7, 23, 59, 59)},
+		{value: "yesterday", expected: date(3, 5, 0, 0, 0), expectedDeadline: date(3, 5, 23, 59, 59)},
+		{value: "tomorrow 9am", expected: date(3, 7, 9, 0, 0)},
+		{value: "tomorrow at 5:30pm", expected: date(3, 7, 17, 30, 0)},
+		{value: "17:00", expected: date(3, 6, 17, 0, 0)},
+		{value: "friday", expected: date(3, 8, 0, 0, 0), expectedDeadline: date(3, 8, 23, 59, 59)},
+		{value: "next friday 17:00", expected: date(3, 8, 17, 0, 0)},
+		{value: "wednesday", expected: date(3, 13, 0, 0, 0), expectedDeadline: date(3, 13, 23, 59, 59)},
+		{value: "this wed", expected: date(3, 6, 0, 0, 0), expectedDeadline: date(3, 6, 23, 59, 59)},
+		{value: "monday", expected: date(3, 11, 0, 0, 0), expectedDeadline: date(3, 11, 23, 59, 59)},
+		{value: "next week", expected: date(3, 13, 0, 0, 0), expectedDeadline: date(3, 13, 23, 59, 59)},
+		{value: "in 3 days", expected: date(3, 9, 0, 0, 0), expectedDeadline: date(3, 9, 23, 59, 59)},
+		{value: "in 1 day 8:00", expected: date(3, 7, 8, 0, 0)},
+		{value: "in 2 weeks", expected: date(3, 20, 0, 0, 0), expectedDeadline: date(3, 20, 23, 59, 59)},
+		{value: "in 1 month", expected: date(4, 6, 0, 0, 0), expectedDeadline: date(4, 6, 23, 59, 59)},
+		{value: "in 2 hours", expected: date(3, 6, 12, 30, 0)},
+		{value: "in 45 minutes", expected: date(3, 6, 11, 15, 0)},
+		{value: "", expectedErr: ErrInvalidDate},
+		{value: "someday", expectedErr: ErrInvalidDate},
+		{value: "in three days", expectedErr: ErrInvalidDate},
+		{value: "in 3 fortnights", expectedErr: ErrInvalidDate},
+		{value: "tomorrow noonish", expectedErr: ErrInvalidDate},
+		{value: "in 2 hours 17:00", expectedErr: ErrInvalidDate},
+		{value: "2024-13-01", expectedErr: ErrInvalidDate},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.value, func(t *testing.T) {
+			got, err := Parse(tc.value, now)
+
+			if tc.expectedErr != nil {
+				if !errors.Is(err, tc.expectedErr) {
+					t.Errorf("Expected error: %q, but got: %q instead", tc.expectedErr, err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Expected no error, but got: %q instead", err)
+			}
+
+			if !got.Equal(tc.expected) {
+				t.Errorf("Expected: %s, but got: %s instead", tc.expected, got)
+			}
+
+			deadline, err := ParseDeadline(tc.value, now)
+			if err != nil {
+				t.Fatalf("Expected no error, but got: %q instead", err)
+			}
+
+			expectedDeadline := tc.expectedDeadline
+			if expectedDeadline.IsZero() {
+				expectedDeadline = tc.expected
+			}
+
+			if !deadline.Equal(expectedDeadline) {
+				t.Errorf("Expected deadline: %s, but got: %s instead", expectedDeadline, deadline)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+// Package fsutil holds the file system helpers shared by the client and the
+// command line.
+package fsutil
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// AtomicFile is a file written in place of another, which it only replaces
+// once committed, so that readers never see a partially written file.
+type AtomicFile struct {
+	*os.File
+
+	path string
+	perm fs.FileMode
+}
+
+// CreateAtomic returns an AtomicFile that replaces the file at path with
+// permissions perm when committed, creating its directory if needed.
+func CreateAtomic(path string, perm fs.FileMode) (*AtomicFile, error) {
+	dir := filepath.Dir(path)
+
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return nil, err
+	}
+
+	return &AtomicFile{File: tmp, path: path, perm: perm}, nil
+}
+
+// Commit replaces the file at the path of f with what was written to f.
+func (f *AtomicFile) Commit() error {
+	defer os.Remove(f.Name())
+
+	if err := f.Chmod(f.perm); err != nil {
+		f.Close()
+
+		return err
+	}
+
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), f.path)
+}
+
+// Abort discards what was written to f, leaving the file at its path as it
+// was.
+func (f *AtomicFile) Abort() {
+	f.Close()
+	os.Remove(f.Name())
+}
+
+// WriteFileAtomic replaces the file at path with data, creating its
+// directory if needed. The data is written to a temporary file first so that
+// readers never see a partially written file.
+func WriteFileAtomic(path string, data []byte, perm fs.FileMode) error {
+	f, err := CreateAtomic(path, perm)
+	if err != nil {
+		return err
+	}
+
+	if _, err := f.Write(data); err != nil {
+		f.Abort()
+
+		return err
+	}
+
+	return f.Commit()
+}
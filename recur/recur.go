@@ -0,0 +1,600 @@
+// Package recur computes the dates of recurring tasks from schedules written
+// as RFC 5545 recurrence rules, such as "FREQ=WEEKLY;BYDAY=MO", or as short
+// phrases, such as "weekly on mon" or "every 2 weeks".
+//
+// Rules are applied from one occurrence to the next, so the supported RRULE
+// parts are FREQ, INTERVAL, BYDAY without ordinals, BYMONTHDAY and UNTIL.
+package recur
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidRule = errors.New("invalid recurrence rule")
+
+// Frequency is the unit of time a rule repeats in.
+type Frequency int
+
+const (
+	Daily Frequency = iota + 1
+	Weekly
+	Monthly
+	Yearly
+)
+
+var frequencyNames = map[Frequency]string{
+	Daily:   "DAILY",
+	Weekly:  "WEEKLY",
+	Monthly: "MONTHLY",
+	Yearly:  "YEARLY",
+}
+
+func (f Frequency) String() string {
+	return frequencyNames[f]
+}
+
+// maxPeriods bounds the search for the next occurrence of rules that can
+// never match, such as the 31st of February.
+const maxPeriods = 1000
+
+// Rule is a recurrence rule. The zero Rule is not valid, use Parse.
+type Rule struct {
+	Freq Frequency
+
+	// Interval is the number of periods between occurrences, 1 or more.
+	Interval int
+
+	// ByDay and ByMonthDay, when set, restrict the occurrences to the
+	// given weekdays and days of the month. Negative days count from the
+	// end of the month, -1 being the last day.
+	ByDay      []time.Weekday
+	ByMonthDay []int
+
+	// Until, when set, is the last time an occurrence can fall on.
+	Until time.Time
+}
+
+// rruleDays holds the two letter weekday names used by RRULE, Monday
+// first as the week starts on Monday.
+var rruleDays = []struct {
+	name string
+	day  time.Weekday
+}{
+	{"MO", time.Monday},
+	{"TU", time.Tuesday},
+	{"WE", time.Wednesday},
+	{"TH", time.Thursday},
+	{"FR", time.Friday},
+	{"SA", time.Saturday},
+	{"SU", time.Sunday},
+}
+
+// untilLayouts are the formats accepted for UNTIL, times ending in Z being
+// in UTC and floating times in the local time zone.
+var untilLayouts = []string{
+	"20060102T150405Z",
+	"20060102T150405",
+	"20060102",
+}
+
+// Parse parses s as an RRULE, with or without the RRULE: prefix, or as a
+// phrase such as:
+//
+//   - daily, weekly, monthly or yearly
+//   - every day, every 2 weeks, every 3 months
+//   - every weekday, every monday, every tue and thu
+//   - weekly on mon, weekly on mon,wed,fri
+//   - monthly on the 15th, monthly on the last day
+//
+// Phrases can end with "until 2024-12-31".
+func Parse(s string) (Rule, error) {
+	s = strings.TrimSpace(s)
+
+	upper := strings.ToUpper(s)
+	if strings.HasPrefix(upper, "RRULE:") || strings.Contains(upper, "FREQ=") {
+		return parseRRule(strings.TrimPrefix(upper, "RRULE:"))
+	}
+
+	return parsePhrase(s)
+}
+
+func parseRRule(s string) (Rule, error) {
+	r := Rule{Interval: 1}
+
+	for _, part := range strings.Split(s, ";") {
+		if part == "" {
+			continue
+		}
+
+		key, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return Rule{}, fmt.Errorf("%w: %q is not KEY=VALUE", ErrInvalidRule, part)
+		}
+
+		var err error
+
+		switch key {
+		case "FREQ":
+			err = r.setFreq(value)
+		case "INTERVAL":
+			r.Interval, err = strconv.Atoi(value)
+			if err == nil && r.Interval < 1 {
+				err = errors.New("must be 1 or more")
+			}
+		case "BYDAY":
+			for _, name := range strings.Split(value, ",") {
+				day, ok := rruleDay(name)
+				if !ok {
+					err = fmt.Errorf("unknown weekday %q", name)
+
+					break
+				}
+
+				r.ByDay = append(r.ByDay, day)
+			}
+		case "BYMONTHDAY":
+			for _, v := range strings.Split(value, ",") {
+				day, convErr := strconv.Atoi(v)
+				if convErr != nil || day == 0 || day < -31 || day > 31 {
+					err = fmt.Errorf("%q is not a day of the month", v)
+
+					break
+				}
+
+				r.ByMonthDay = append(r.ByMonthDay, day)
+			}
+		case "UNTIL":
+			r.Until, err = parseUntil(value)
+		case "WKST":
+			if value != "MO" {
+				err = errors.New("only weeks starting on MO are supported")
+			}
+		case "COUNT":
+			err = errors.New("not supported, use UNTIL")
+		default:
+			err = errors.New("unknown rule part")
+		}
+
+		if err != nil {
+			return Rule{}, fmt.Errorf("%w: %s: %s", ErrInvalidRule, key, err)
+		}
+	}
+
+	if r.Freq == 0 {
+		return Rule{}, fmt.Errorf("%w: FREQ is missing", ErrInvalidRule)
+	}
+
+	return r.normalized(), nil
+}
+
+func (r *Rule) setFreq(value string) error {
+	for f, name := range frequencyNames {
+		if name == value {
+			r.Freq = f
+
+			return nil
+		}
+	}
+
+	return fmt.Errorf("unknown frequency %q", value)
+}
+
+func rruleDay(name string) (time.Weekday, bool) {
+	for _, d := range rruleDays {
+		if d.name == name {
+			return d.day, true
+		}
+	}
+
+	return 0, false
+}
+
+func parseUntil(value string) (time.Time, error) {
+	for _, layout := range untilLayouts {
+		loc := time.Local
+		if strings.HasSuffix(layout, "Z") {
+			loc = time.UTC
+		}
+
+		t, err := time.ParseInLocation(layout, value, loc)
+		if err != nil {
+			continue
+		}
+
+		if layout == "20060102" {
+			t = t.Add(24*time.Hour - time.Second)
+		}
+
+		return t, nil
+	}
+
+	return time.Time{}, fmt.Errorf("%q is not a date", value)
+}
+
+// phraseUnits maps the words naming a period to its frequency.
+var phraseUnits = map[string]Frequency{
+	"daily":    Daily,
+	"day":      Daily,
+	"weekly":   Weekly,
+	"week":     Weekly,
+	"monthly":  Monthly,
+	"month":    Monthly,
+	"yearly":   Yearly,
+	"annually": Yearly,
+	"year":     Yearly,
+}
+
+var weekdayNames = map[string]time.Weekday{
+	"monday":    time.Monday,
+	"tuesday":   time.Tuesday,
+	"wednesday": time.Wednesday,
+	"thursday":  time.Thursday,
+	"friday":    time.Friday,
+	"saturday":  time.Saturday,
+	"sunday":    time.Sunday,
+	"mon":       time.Monday,
+	"tue":       time.Tuesday,
+	"wed":       time.Wednesday,
+	"thu":       time.Thursday,
+	"fri":       time.Friday,
+	"sat":       time.Saturday,
+	"sun":       time.Sunday,
+}
+
+func phraseWeekday(word string) (time.Weekday, bool) {
+	if d, ok := weekdayNames[word]; ok {
+		return d, true
+	}
+
+	d, ok := weekdayNames[strings.TrimSuffix(word, "s")]
+
+	return d, ok
+}
+
+func parsePhrase(s string) (Rule, error) {
+	words := strings.Fields(strings.ToLower(strings.ReplaceAll(s, ",", " ")))
+	if len(words) == 0 {
+		return Rule{}, fmt.Errorf("%w: empty rule", ErrInvalidRule)
+	}
+
+	fail := func(format string, args ...interface{}) (Rule, error) {
+		return Rule{}, fmt.Errorf("%w: %q: %s", ErrInvalidRule, s, fmt.Sprintf(format, args...))
+	}
+
+	r := Rule{Interval: 1}
+
+	if words[0] == "every" {
+		words = words[1:]
+
+		if len(words) > 0 {
+			if n, err := strconv.Atoi(words[0]); err == nil {
+				if n < 1 {
+					return fail("the interval must be 1 or more")
+				}
+
+				r.Interval = n
+				words = words[1:]
+			}
+		}
+	}
+
+	if len(words) == 0 {
+		return fail("expected a period such as day or week")
+	}
+
+	unit := words[0]
+	words = words[1:]
+
+	switch {
+	case unit == "weekday" || unit == "weekdays":
+		r.Freq = Weekly
+		r.ByDay = []time.Weekday{
+			time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday,
+		}
+
+	case phraseUnits[strings.TrimSuffix(unit, "s")] != 0:
+		r.Freq = phraseUnits[strings.TrimSuffix(unit, "s")]
+
+	default:
+		day, ok := phraseWeekday(unit)
+		if !ok {
+			return fail("unknown period %q", unit)
+		}
+
+		r.Freq = Weekly
+		r.ByDay = []time.Weekday{day}
+	}
+
+	for len(words) > 0 {
+		switch w := words[0]; {
+		case w == "on" || w == "and":
+			words = words[1:]
+
+		case w == "until":
+			if len(words) < 2 {
+				return fail("expected a date after until")
+			}
+
+			until, err := time.ParseInLocation("2006-01-02", words[1], time.Local)
+			if err != nil {
+				return fail("%q is not a date such as 2024-12-31", words[1])
+			}
+
+			r.Until = until.Add(24*time.Hour - time.Second)
+			words = words[2:]
+
+		case r.Freq == Monthly || r.Freq == Yearly:
+			day, n, err := phraseMonthDay(words)
+			if err != nil {
+				return fail("%s", err)
+			}
+
+			r.ByMonthDay = append(r.ByMonthDay, day)
+			words = words[n:]
+
+		default:
+			day, ok := phraseWeekday(w)
+			if !ok {
+				return fail("unexpected %q", w)
+			}
+
+			r.ByDay = append(r.ByDay, day)
+			words = words[1:]
+		}
+	}
+
+	return r.normalized(), nil
+}
+
+// phraseMonthDay parses a day of the month such as "the 15th", "15" or
+// "the last day" at the start of words, returning the number of words read.
+func phraseMonthDay(words []string) (int, int, error) {
+	n := 0
+
+	if words[0] == "the" {
+		n++
+	}
+
+	if n >= len(words) {
+		return 0, 0, errors.New("expected a day of the month")
+	}
+
+	if words[n] == "last" {
+		n++
+
+		if n < len(words) && words[n] == "day" {
+			n++
+		}
+
+		return -1, n, nil
+	}
+
+	digits := strings.TrimRight(words[n], "stndrh")
+
+	day, err := strconv.Atoi(digits)
+	if err != nil || day < 1 || day > 31 {
+		return 0, 0, fmt.Errorf("%q is not a day of the month", words[n])
+	}
+
+	return day, n + 1, nil
+}
+
+// normalized sorts and removes duplicates from the days of r.
+func (r Rule) normalized() Rule {
+	weekOrder := func(d time.Weekday) int { return (int(d) + 6) % 7 }
+
+	sort.Slice(r.ByDay, func(i, j int) bool {
+		return weekOrder(r.ByDay[i]) < weekOrder(r.ByDay[j])
+	})
+	sort.Ints(r.ByMonthDay)
+
+	var (
+		days      []time.Weekday
+		monthDays []int
+	)
+
+	for i, d := range r.ByDay {
+		if i == 0 || d != r.ByDay[i-1] {
+			days = append(days, d)
+		}
+	}
+
+	for i, d := range r.ByMonthDay {
+		if i == 0 || d != r.ByMonthDay[i-1] {
+			monthDays = append(monthDays, d)
+		}
+	}
+
+	r.ByDay, r.ByMonthDay = days, monthDays
+
+	return r
+}
+
+// String returns r as an RRULE, without the RRULE: prefix.
+func (r Rule) String() string {
+	parts := []string{"FREQ=" + r.Freq.String()}
+
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+
+	if len(r.ByDay) > 0 {
+		names := make([]string, len(r.ByDay))
+
+		for i, day := range r.ByDay {
+			for _, d := range rruleDays {
+				if d.day == day {
+					names[i] = d.name
+				}
+			}
+		}
+
+		parts = append(parts, "BYDAY="+strings.Join(names, ","))
+	}
+
+	if len(r.ByMonthDay) > 0 {
+		days := make([]string, len(r.ByMonthDay))
+
+		for i, day := range r.ByMonthDay {
+			days[i] = strconv.Itoa(day)
+		}
+
+		parts = append(parts, "BYMONTHDAY="+strings.Join(days, ","))
+	}
+
+	if !r.Until.IsZero() {
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format(untilLayouts[0]))
+	}
+
+	return strings.Join(parts, ";")
+}
+
+// First returns start if it is an occurrence of r, or the next one after
+// it otherwise. Without ByDay or ByMonthDay, start is always an occurrence.
+func (r Rule) First(start time.Time) time.Time {
+	if r.matches(start, start) {
+		return r.bounded(start)
+	}
+
+	return r.Next(start)
+}
+
+// Next returns the first occurrence of r after the occurrence at after, at
+// the same time of day, or the zero time if there is none before Until.
+func (r Rule) Next(after time.Time) time.Time {
+	interval := r.Interval
+	if interval < 1 {
+		interval = 1
+	}
+
+	start := r.periodStart(after)
+
+	for k := 0; k < maxPeriods; k += interval {
+		from := r.addPeriods(start, k)
+		to := r.addPeriods(start, k+1)
+
+		for d := from; d.Before(to); d = d.AddDate(0, 0, 1) {
+			if !r.Until.IsZero() && d.After(r.Until) {
+				return time.Time{}
+			}
+
+			if d.After(after) && r.matches(d, after) {
+				return d
+			}
+		}
+	}
+
+	return time.Time{}
+}
+
+// NextN returns the next n occurrences of r after the one at after.
+func (r Rule) NextN(after time.Time, n int) []time.Time {
+	var dates []time.Time
+
+	for len(dates) < n {
+		after = r.Next(after)
+		if after.IsZero() {
+			break
+		}
+
+		dates = append(dates, after)
+	}
+
+	return dates
+}
+
+func (r Rule) bounded(t time.Time) time.Time {
+	if !r.Until.IsZero() && t.After(r.Until) {
+		return time.Time{}
+	}
+
+	return t
+}
+
+// periodStart returns the first day of the period t falls in, at the time
+// of day of t. Weeks start on Monday.
+func (r Rule) periodStart(t time.Time) time.Time {
+	year, month, day := t.Date()
+
+	switch r.Freq {
+	case Weekly:
+		day -= (int(t.Weekday()) + 6) % 7
+	case Monthly:
+		day = 1
+	case Yearly:
+		month, day = time.January, 1
+	}
+
+	return time.Date(
+		year, month, day, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location(),
+	)
+}
+
+func (r Rule) addPeriods(start time.Time, n int) time.Time {
+	switch r.Freq {
+	case Weekly:
+		return start.AddDate(0, 0, 7*n)
+	case Monthly:
+		return start.AddDate(0, n, 0)
+	case Yearly:
+		return start.AddDate(n, 0, 0)
+	}
+
+	return start.AddDate(0, 0, n)
+}
+
+// matches reports whether the day of t is an occurrence of r. Without
+// ByDay or ByMonthDay, occurrences fall on the same day of the period as
+// anchor.
+func (r Rule) matches(t, anchor time.Time) bool {
+	if len(r.ByDay) == 0 && len(r.ByMonthDay) == 0 {
+		switch r.Freq {
+		case Weekly:
+			return t.Weekday() == anchor.Weekday()
+		case Monthly:
+			return t.Day() == anchor.Day()
+		case Yearly:
+			return t.Month() == anchor.Month() && t.Day() == anchor.Day()
+		}
+
+		return true
+	}
+
+	if len(r.ByDay) > 0 && !containsDay(r.ByDay, t.Weekday()) {
+		return false
+	}
+
+	if len(r.ByMonthDay) > 0 {
+		// The last day of the month is -1.
+		fromEnd := t.Day() - daysIn(t.Year(), t.Month()) - 1
+
+		for _, d := range r.ByMonthDay {
+			if d == t.Day() || d == fromEnd {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	return true
+}
+
+func containsDay(days []time.Weekday, day time.Weekday) bool {
+	for _, d := range days {
+		if d == day {
+			return true
+		}
+	}
+
+	return false
+}
+
+func daysIn(year int, month time.Month) int {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
@@ -0,0 +1,292 @@
+package recur
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	testCases := []struct {
+		value       string
+		expected    string
+		expectedErr error
+	}{
+		{value: "daily", expected: "FREQ=DAILY"},
+		{value: "Every day", expected: "FREQ=DAILY"},
+		{value: "every 3 days", expected: "FREQ=DAILY;INTERVAL=3"},
+		{value: "weekly", expected: "FREQ=WEEKLY"},
+		{value: "every 2 weeks", expected: "FREQ=WEEKLY;INTERVAL=2"},
+		{value: "weekly on mon", expected: "FREQ=WEEKLY;BYDAY=MO"},
+		{value: "weekly on fri,mon,wed", expected: "FREQ=WEEKLY;BYDAY=MO,WE,FR"},
+		{value: "every 2 weeks on tuesday and thursday", expected: "FREQ=WEEKLY;INTERVAL=2;BYDAY=TU,TH"},
+		{value: "every monday", expected: "FREQ=WEEKLY;BYDAY=MO"},
+		{value: "every sun and sat", expected: "FREQ=WEEKLY;BYDAY=SA,SU"},
+		{value: "every weekday", expected: "FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR"},
+		{value: "monthly", expected: "FREQ=MONTHLY"},
+		{value: "monthly on the 15th", expected: "FREQ=MONTHLY;BYMONTHDAY=15"},
+		{value: "every month on the 1st and the 15th", expected: "FREQ=MONTHLY;BYMONTHDAY=1,15"},
+		{value: "monthly on the last day", expected: "FREQ=MONTHLY;BYMONTHDAY=-1"},
+		{value: "yearly", expected: "FREQ=YEARLY"},
+		{value: "annually", expected: "FREQ=YEARLY"},
+		{
+			value:    "weekly on mon until 2024-12-31",
+			expected: "FREQ=WEEKLY;BYDAY=MO;UNTIL=" + endOfDay(2024, time.December, 31),
+		},
+		{value: "FREQ=WEEKLY;BYDAY=MO", expected: "FREQ=WEEKLY;BYDAY=MO"},
+		{value: "RRULE:FREQ=DAILY;INTERVAL=1", expected: "FREQ=DAILY"},
+		{value: "rrule:freq=monthly;bymonthday=-1", expected: "FREQ=MONTHLY;BYMONTHDAY=-1"},
+		{
+			value:    "FREQ=WEEKLY;INTERVAL=2;BYDAY=WE,MO;WKST=MO",
+			expected: "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,WE",
+		},
+		{
+			value:    "FREQ=DAILY;UNTIL=20240301T120000Z",
+			expected: "FREQ=DAILY;UNTIL=20240301T120000Z",
+		},
+		{value: "", expectedErr: ErrInvalidRule},
+		{value: "every", expectedErr: ErrInvalidRule},
+		{value: "every 0 days", expectedErr: ErrInvalidRule},
+		{value: "sometimes", expectedErr: ErrInvalidRule},
+		{value: "weekly on funday", expectedErr: ErrInvalidRule},
+		{value: "monthly on the 32nd", expectedErr: ErrInvalidRule},
+		{value: "weekly until soon", expectedErr: ErrInvalidRule},
+		{value: "INTERVAL=2", expectedErr: ErrInvalidRule},
+		{value: "FREQ=HOURLY", expectedErr: ErrInvalidRule},
+		{value: "FREQ=WEEKLY;BYDAY=1MO", expectedErr: ErrInvalidRule},
+		{value: "FREQ=DAILY;COUNT=5", expectedErr: ErrInvalidRule},
+		{value: "FREQ=DAILY;INTERVAL=0", expectedErr: ErrInvalidRule},
+		{value: "FREQ=MONTHLY;BYMONTHDAY=0", expectedErr: ErrInvalidRule},
+		{value: "FREQ=DAILY;BYHOUR=9", expectedErr: ErrInvalidRule},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.value, func(t *testing.T) {
+			r, err := Parse(tc.value)
+
+			if tc.expectedErr != nil {
+				if !errors.Is(err, tc.expectedErr) {
+					t.Errorf("Expected error: %q, but got: %q instead", tc.expectedErr, err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Expected no error, but got: %q instead", err)
+			}
+
+			if tc.expected != r.String() {
+				t.Errorf("Expected rule: %q, but got: %q instead", tc.expected, r.String())
+			}
+
+			// The RRULE of a rule parses back to the same rule.
+			again, err := Parse(r.String())
+			if err != nil {
+				t.Fatalf("Expected no error parsing %q, but got: %q instead", r.String(), err)
+			}
+
+			if r.String() != again.String() {
+				t.Errorf("Expected rule: %q, but got: %q instead", r.String(), again.String())
+			}
+		})
+	}
+}
+
+func TestUntilRoundTrip(t *testing.T) {
+	local := time.Local
+	time.Local = time.FixedZone("UTC-5", -5*60*60)
+
+	defer func() { time.Local = local }()
+
+	for _, value := range []string{
+		"FREQ=DAILY;UNTIL=20240301T120000Z",
+		"FREQ=DAILY;UNTIL=20240301T120000",
+		"FREQ=DAILY;UNTIL=20240301",
+	} {
+		t.Run(value, func(t *testing.T) {
+			r, err := Parse(value)
+			if err != nil {
+				t.Fatalf("Expected no error, but got: %q instead", err)
+			}
+
+			again, err := Parse(r.String())
+			if err != nil {
+				t.Fatalf("Expected no error parsing %q, but got: %q instead", r.String(), err)
+			}
+
+			if !again.Until.Equal(r.Until) {
+				t.Errorf("Expected until: %v, but got: %v instead", r.Until, again.Until)
+			}
+		})
+	}
+
+	r, _ := Parse("FREQ=DAILY;UNTIL=20240301T120000Z")
+
+	if expected := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC); !r.Until.Equal(expected) {
+		t.Errorf("Expected until: %v, but got: %v instead", expected, r.Until)
+	}
+}
+
+func endOfDay(year int, month time.Month, day int) string {
+	return time.Date(year, month, day, 23, 59, 59, 0, time.Local).UTC().Format("20060102T150405Z")
+}
+
+func TestNext(t *testing.T) {
+	zone := time.FixedZone("UTC-5", -5*60*60)
+
+	date := func(month time.Month, day int) time.Time {
+		return time.Date(2024, month, day, 17, 0, 0, 0, zone)
+	}
+
+	// A Wednesday.
+	wednesday := date(time.March, 6)
+
+	testCases := []struct {
+		rule     string
+		after    time.Time
+		expected []time.Time
+	}{
+		{
+			rule:     "daily",
+			after:    wednesday,
+			expected: []time.Time{date(3, 7), date(3, 8), date(3, 9)},
+		},
+		{
+			rule:     "every 3 days",
+			after:    wednesday,
+			expected: []time.Time{date(3, 9), date(3, 12), date(3, 15)},
+		},
+		{
+			rule:     "weekly",
+			after:    wednesday,
+			expected: []time.Time{date(3, 13), date(3, 20), date(3, 27)},
+		},
+		{
+			rule:     "weekly on mon",
+			after:    wednesday,
+			expected: []time.Time{date(3, 11), date(3, 18), date(3, 25)},
+		},
+		{
+			rule:     "weekly on mon,wed,fri",
+			after:    wednesday,
+			expected: []time.Time{date(3, 8), date(3, 11), date(3, 13)},
+		},
+		{
+			rule:     "every 2 weeks on mon and thu",
+			after:    wednesday,
+			expected: []time.Time{date(3, 7), date(3, 18), date(3, 21)},
+		},
+		{
+			rule:     "every weekday",
+			after:    date(time.March, 8),
+			expected: []time.Time{date(3, 11), date(3, 12), date(3, 13)},
+		},
+		{
+			rule:     "FREQ=DAILY;BYDAY=SA,SU",
+			after:    wednesday,
+			expected: []time.Time{date(3, 9), date(3, 10), date(3, 16)},
+		},
+		{
+			rule:     "monthly",
+			after:    wednesday,
+			expected: []time.Time{date(4, 6), date(5, 6), date(6, 6)},
+		},
+		{
+			rule:     "monthly",
+			after:    date(time.January, 31),
+			expected: []time.Time{date(3, 31), date(5, 31), date(7, 31)},
+		},
+		{
+			rule:     "monthly on the 15th",
+			after:    wednesday,
+			expected: []time.Time{date(3, 15), date(4, 15), date(5, 15)},
+		},
+		{
+			rule:     "monthly on the last day",
+			after:    date(time.January, 31),
+			expected: []time.Time{date(2, 29), date(3, 31), date(4, 30)},
+		},
+		{
+			rule:     "every 3 months on the 1st",
+			after:    date(time.January, 15),
+			expected: []time.Time{date(4, 1), date(7, 1), date(10, 1)},
+		},
+		{
+			rule:  "yearly",
+			after: date(time.February, 29),
+			expected: []time.Time{
+				time.Date(2028, time.February, 29, 17, 0, 0, 0, zone),
+				time.Date(2032, time.February, 29, 17, 0, 0, 0, zone),
+				time.Date(2036, time.February, 29, 17, 0, 0, 0, zone),
+			},
+		},
+		{
+			rule:     "FREQ=DAILY;UNTIL=20240308T220000Z",
+			after:    wednesday,
+			expected: []time.Time{date(3, 7), date(3, 8)},
+		},
+		{
+			rule:  "FREQ=MONTHLY;BYMONTHDAY=31;BYDAY=FR",
+			after: wednesday,
+			expected: []time.Time{
+				date(5, 31),
+				time.Date(2025, time.January, 31, 17, 0, 0, 0, zone),
+				time.Date(2025, time.October, 31, 17, 0, 0, 0, zone),
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.rule, func(t *testing.T) {
+			r, err := Parse(tc.rule)
+			if err != nil {
+				t.Fatalf("Expected no error, but got: %q instead", err)
+			}
+
+			dates := r.NextN(tc.after, 3)
+
+			if len(dates) != len(tc.expected) {
+				t.Fatalf("Expected dates: %v, but got: %v instead", tc.expected, dates)
+			}
+
+			for i := range dates {
+				if !tc.expected[i].Equal(dates[i]) {
+					t.Errorf("Expected dates: %v, but got: %v instead", tc.expected, dates)
+
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestFirst(t *testing.T) {
+	// A Wednesday.
+	wednesday := time.Date(2024, time.March, 6, 23, 59, 59, 0, time.UTC)
+
+	testCases := []struct {
+		rule     string
+		expected time.Time
+	}{
+		{rule: "daily", expected: wednesday},
+		{rule: "weekly", expected: wednesday},
+		{rule: "weekly on wed", expected: wednesday},
+		{rule: "weekly on mon", expected: wednesday.AddDate(0, 0, 5)},
+		{rule: "monthly on the 1st", expected: wednesday.AddDate(0, 0, 26)},
+		{rule: "FREQ=DAILY;UNTIL=20240301T000000Z", expected: time.Time{}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.rule, func(t *testing.T) {
+			r, err := Parse(tc.rule)
+			if err != nil {
+				t.Fatalf("Expected no error, but got: %q instead", err)
+			}
+
+			if first := r.First(wednesday); !tc.expected.Equal(first) {
+				t.Errorf("Expected date: %s, but got: %s instead", tc.expected, first)
+			}
+		})
+	}
+}